@@ -0,0 +1,32 @@
+// Code generated by hade config gen. DO NOT EDIT.
+
+package config
+
+import (
+	"time"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+// AppConfig 对应 app 配置
+type AppConfig struct {
+	DevFresh int `yaml:"dev_fresh"`
+	Health   struct {
+		DiskMinFree int           `yaml:"disk_min_free"`
+		Timeout     time.Duration `yaml:"timeout"`
+	} `yaml:"health"`
+	Modules struct {
+		Demo bool `yaml:"demo"`
+	} `yaml:"modules"`
+	Swagger     bool `yaml:"swagger"`
+	SwaggerOpen bool `yaml:"swagger_open"`
+}
+
+// LoadApp 使用 Config.Load 读取 app 配置
+func LoadApp(c contract.Config) (*AppConfig, error) {
+	conf := &AppConfig{}
+	if err := c.Load("app", conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
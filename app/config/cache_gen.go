@@ -0,0 +1,31 @@
+// Code generated by hade config gen. DO NOT EDIT.
+
+package config
+
+import (
+	"time"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+// CacheConfig 对应 cache 配置
+type CacheConfig struct {
+	Driver string `yaml:"driver"`
+	Redis  struct {
+		Db           int           `yaml:"db"`
+		Host         string        `yaml:"host"`
+		Port         int           `yaml:"port"`
+		ReadTimeout  time.Duration `yaml:"read_timeout"`
+		Timeout      time.Duration `yaml:"timeout"`
+		WriteTimeout time.Duration `yaml:"write_timeout"`
+	} `yaml:"redis"`
+}
+
+// LoadCache 使用 Config.Load 读取 cache 配置
+func LoadCache(c contract.Config) (*CacheConfig, error) {
+	conf := &CacheConfig{}
+	if err := c.Load("cache", conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
@@ -0,0 +1,45 @@
+// Code generated by hade config gen. DO NOT EDIT.
+
+package config
+
+import (
+	"time"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+// DatabaseConfig 对应 database 配置
+type DatabaseConfig struct {
+	ConnMaxIdle     int           `yaml:"conn_max_idle"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxOpen     int           `yaml:"conn_max_open"`
+	Default         struct {
+		AllowNativePasswords bool          `yaml:"allow_native_passwords"`
+		Charset              string        `yaml:"charset"`
+		Collation            string        `yaml:"collation"`
+		Database             string        `yaml:"database"`
+		Driver               string        `yaml:"driver"`
+		Dsn                  string        `yaml:"dsn"`
+		Host                 string        `yaml:"host"`
+		Loc                  string        `yaml:"loc"`
+		ParseTime            bool          `yaml:"parse_time"`
+		Password             string        `yaml:"password"`
+		Port                 int           `yaml:"port"`
+		Protocol             string        `yaml:"protocol"`
+		ReadTimeout          time.Duration `yaml:"read_timeout"`
+		Timeout              time.Duration `yaml:"timeout"`
+		Username             string        `yaml:"username"`
+		WriteTimeout         time.Duration `yaml:"write_timeout"`
+	} `yaml:"default"`
+	Loc      string `yaml:"loc"`
+	Protocol string `yaml:"protocol"`
+}
+
+// LoadDatabase 使用 Config.Load 读取 database 配置
+func LoadDatabase(c contract.Config) (*DatabaseConfig, error) {
+	conf := &DatabaseConfig{}
+	if err := c.Load("database", conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
@@ -35,9 +35,8 @@ var FooCommand = &cobra.Command{
 	Example: "foo命令的例子",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		log.Println("this is foo command")
-		container := cmd.GetContainer()
-		configService := container.MustMake(contract.ConfigKey).(contract.Config)
-		log.Println(configService.Get("app.url"))
-		return nil
+		return cmd.Invoke(func(configService contract.Config) {
+			log.Println(configService.Get("app.url"))
+		})
 	},
 }
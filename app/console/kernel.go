@@ -1,12 +1,10 @@
 package console
 
 import (
-	"time"
-
-	"github.com/yefangyong/go-frame/app/console/command/demo"
 	"github.com/yefangyong/go-frame/framework"
 	"github.com/yefangyong/go-frame/framework/cobra"
 	"github.com/yefangyong/go-frame/framework/command"
+	"github.com/yefangyong/go-frame/framework/module"
 )
 
 func RunCommand(container framework.Container) error {
@@ -31,17 +29,14 @@ func RunCommand(container framework.Container) error {
 	// 绑定业务的命令
 	AddAppCommand(rootCmd)
 
+	// 绑定开启的模块的命令和定时任务
+	module.RegisterCommands(rootCmd)
+
 	// 执行RootCommand
 	return rootCmd.Execute()
 }
 
 // 业务的相关命令
+// 按照功能组织的命令和定时任务放在模块中，参考 app/module/demo
 func AddAppCommand(rootCmd *cobra.Command) {
-
-	rootCmd.AddCommand(demo.InitFoo())
-
-	//rootCmd.AddCronCommand("* * * * * *", demo.Foo1Command)
-
-	// 启动一个分布式任务调度，调度的服务名称为init_func_for_test，每个节点每5s调用一次Foo命令，抢占到了调度任务的节点将抢占锁持续挂载2s才释放
-	rootCmd.AddDistributedCronCommand("foo_func_for_test", "*/5 * * * * *", demo.FooCommand, 2*time.Second)
 }
@@ -1,10 +1,23 @@
 package http
 
-import "github.com/yefangyong/go-frame/framework/gin"
+import (
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/gin"
+	"github.com/yefangyong/go-frame/framework/middleware"
+	"github.com/yefangyong/go-frame/framework/module"
+)
 
-func NewHttpEngine() (*gin.Engine, error) {
+func NewHttpEngine(container framework.Container) (*gin.Engine, error) {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
+	// 注册路由之前设置服务容器，路由注册的时候就可以使用容器中的服务
+	r.SetContainer(container)
+	// 每个请求使用独立的子作用域
+	r.Use(middleware.RequestScope())
 	Routes(r)
+	// 注册开启的模块的路由
+	if err := module.RegisterRoutes(r); err != nil {
+		return nil, err
+	}
 	return r, nil
 }
@@ -0,0 +1,87 @@
+package cors
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/gin"
+)
+
+// FromConfig 使用配置服务中 key 对应的配置创建跨域中间件，配置热更新之后使用新的配置
+//
+//	cors:
+//	  allow_origins: ["https://a.example.com", "https://*.example.com"] # 为空的时候允许所有来源
+//	  allow_methods: ["GET", "POST"]
+//	  allow_headers: ["Origin", "Content-Type"]
+//	  expose_headers: []
+//	  allow_credentials: false
+//	  max_age: 12h
+func FromConfig(key string) gin.HandlerFunc {
+	var (
+		current atomic.Value
+		once    sync.Once
+	)
+	return func(c *gin.Context) {
+		once.Do(func() {
+			configService := c.MustMake(contract.ConfigKey).(contract.Config)
+			cors, err := corsFromConfig(configService, key)
+			if err != nil {
+				panic(err.Error())
+			}
+			current.Store(cors)
+			configService.Watch(key, func(oldVal, newVal interface{}) {
+				cors, err := corsFromConfig(configService, key)
+				if err != nil {
+					// 新的配置有错误的时候继续使用之前的配置
+					log.Println("reload cors config error:", err)
+					return
+				}
+				current.Store(cors)
+			})
+		})
+		current.Load().(*cors).applyCors(c)
+	}
+}
+
+// corsFromConfig 读取配置生成 cors，配置不合法的时候返回错误
+func corsFromConfig(configService contract.Config, key string) (ret *cors, err error) {
+	config := DefaultConfig()
+	config.AllowOrigins = configService.GetStringSlice(key + ".allow_origins")
+	config.AllowAllOrigins = len(config.AllowOrigins) == 0
+	if configService.IsExist(key + ".allow_methods") {
+		config.AllowMethods = configService.GetStringSlice(key + ".allow_methods")
+	}
+	if configService.IsExist(key + ".allow_headers") {
+		config.AllowHeaders = configService.GetStringSlice(key + ".allow_headers")
+	}
+	config.ExposeHeaders = configService.GetStringSlice(key + ".expose_headers")
+	config.AllowCredentials = configService.GetBool(key + ".allow_credentials")
+	if configService.IsExist(key + ".max_age") {
+		maxAge, err := time.ParseDuration(configService.GetString(key + ".max_age"))
+		if err != nil {
+			return nil, err
+		}
+		config.MaxAge = maxAge
+	}
+	for _, origin := range config.AllowOrigins {
+		if strings.Contains(origin, "*") {
+			config.AllowWildcard = true
+		}
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	// newCors 在通配符不合法的时候会 panic，转换为错误返回
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	return newCors(config), nil
+}
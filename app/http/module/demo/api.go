@@ -1,8 +1,6 @@
 package demo
 
 import (
-	"fmt"
-
 	"github.com/yefangyong/go-frame/app/provider/demo"
 	"github.com/yefangyong/go-frame/framework/contract"
 	"github.com/yefangyong/go-frame/framework/gin"
@@ -12,9 +10,9 @@ type DemoApi struct {
 	service *Service
 }
 
+// Register 注册 demo 相关的路由，demo 服务由 demo 模块绑定
 func Register(r *gin.Engine) error {
 	demoApi := NewDemoApi()
-	r.Bind(&demo.DemoServiceProvider{})
 	r.GET("demo", demoApi.DemoRedis)
 	r.GET("demo2", demoApi.Demo2)
 	r.GET("demo3", demoApi.Demo3)
@@ -31,17 +29,24 @@ func NewDemoApi() *DemoApi {
 }
 
 func (d *DemoApi) Demo2(ctx *gin.Context) {
-	service := ctx.MustMake(demo.DemoKey).(demo.Service)
-	students := service.GetAllStudent()
-	data := StudentsToUserDTOs(students)
-	ctx.JSON(200, data)
+	err := ctx.Invoke(func(service demo.Service) {
+		students := service.GetAllStudent()
+		data := StudentsToUserDTOs(students)
+		ctx.JSON(200, data)
+	})
+	if err != nil {
+		ctx.AbortWithError(500, err)
+	}
 }
 
 func (d *DemoApi) Demo3(ctx *gin.Context) {
-	app := ctx.MustMake(contract.AppKey).(contract.App)
-	baseFolder := app.BaseFolder()
-	fmt.Println("this is test")
-	ctx.JSON(200, baseFolder)
+	err := ctx.Invoke(func(app contract.App) {
+		baseFolder := app.BaseFolder()
+		ctx.JSON(200, baseFolder)
+	})
+	if err != nil {
+		ctx.AbortWithError(500, err)
+	}
 }
 
 // Demo godoc
@@ -8,11 +8,22 @@ import (
 	"github.com/yefangyong/go-frame/framework/provider/redis"
 )
 
+// cacheDeps DemoCache 依赖的服务，由容器注入
+type cacheDeps struct {
+	Logger contract.Log          `hade:"inject"`
+	Cache  contract.CacheService `hade:"inject"`
+}
+
 func (api *DemoApi) DemoCache(c *gin.Context) {
-	logger := c.MustMake(contract.LogKey).(contract.Log)
-	logger.Info(c, "request start", map[string]interface{}{})
+	deps := &cacheDeps{}
+	if err := c.Fill(deps); err != nil {
+		c.AbortWithError(500, err)
+		return
+	}
+	logger := deps.Logger.With(map[string]interface{}{"module": "demo"})
+	logger.Info(c, "request start", nil)
 	// 初始化cache服务
-	cacheService := c.MustMake(contract.CacheKey).(contract.CacheService)
+	cacheService := deps.Cache
 	// 设置key为foo
 	err := cacheService.Set(c, "foo", "bar", 1*time.Hour)
 	if err != nil {
@@ -37,31 +48,31 @@ func (api *DemoApi) DemoCache(c *gin.Context) {
 }
 
 func (api *DemoApi) DemoRedis(c *gin.Context) {
-	logger := c.MustMake(contract.LogKey).(contract.Log)
-	logger.Info(c, "request start", map[string]interface{}{})
+	logger := c.MustMake(contract.LogKey).(contract.Log).With(map[string]interface{}{"module": "demo"})
+	logger.Info(c, "request start", nil)
 	redisService := c.MustMake(contract.RedisKey).(contract.RedisService)
 	redisClient, err := redisService.GetClient(redis.WithConfigPath("cache.redis"), redis.WithRedisConfig(func(config *contract.RedisConfig) {
 		config.MaxRetries = 3
 	}))
 	if err != nil {
-		logger.Error(c, err.Error(), map[string]interface{}{})
+		logger.Error(c, err.Error(), nil)
 		c.AbortWithError(500, err)
 		return
 	}
 	key := "test"
 	err = redisClient.Set(c, key, "1234", 0).Err()
 	if err != nil {
-		logger.Error(c, err.Error(), map[string]interface{}{})
+		logger.Error(c, err.Error(), nil)
 		c.AbortWithError(500, err)
 		return
 	}
-	logger.Info(c, "设置缓存成功", map[string]interface{}{})
+	logger.Info(c, "设置缓存成功", nil)
 	err = redisClient.Get(c, key).Err()
 	if err != nil {
-		logger.Error(c, err.Error(), map[string]interface{}{})
+		logger.Error(c, err.Error(), nil)
 		c.AbortWithError(500, err)
 		return
 	}
-	logger.Info(c, "获取缓存成功", map[string]interface{}{})
+	logger.Info(c, "获取缓存成功", nil)
 	c.JSON(200, "2323")
 }
@@ -10,13 +10,13 @@ import (
 )
 
 func (api *DemoApi) DemoOrm(c *gin.Context) {
-	logger := c.MustMake(contract.LogKey).(contract.Log)
-	logger.Info(c, "request start", map[string]interface{}{})
+	logger := c.MustMake(contract.LogKey).(contract.Log).With(map[string]interface{}{"module": "demo"})
+	logger.Info(c, "request start", nil)
 	// 初始化一个orm.DB
 	gormService := c.MustMake(contract.ORMKEY).(contract.ORMService)
 	db, err := gormService.GetDB(orm.WithConfigPath("database.default"))
 	if err != nil {
-		logger.Error(c, err.Error(), map[string]interface{}{})
+		logger.Error(c, err.Error(), nil)
 		c.AbortWithError(500, err)
 		return
 	}
@@ -28,7 +28,7 @@ func (api *DemoApi) DemoOrm(c *gin.Context) {
 		c.AbortWithError(500, err)
 		return
 	}
-	logger.Info(c, "migrate ok", map[string]interface{}{})
+	logger.Info(c, "migrate ok", nil)
 
 	// 插入一条数据
 	email := "foo@gmail.com"
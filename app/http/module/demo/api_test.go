@@ -0,0 +1,34 @@
+package demo
+
+import (
+	"testing"
+
+	"github.com/yefangyong/go-frame/app/provider/demo"
+	hadetest "github.com/yefangyong/go-frame/framework/testing"
+)
+
+type fakeDemoService struct{}
+
+func (f *fakeDemoService) GetAllStudent() []demo.Student {
+	return []demo.Student{{ID: 7, Name: "fake"}}
+}
+
+func TestDemoApi(t *testing.T) {
+	tc := hadetest.NewTestContainer(t)
+	r := tc.NewEngine(Register)
+	tc.Override(demo.DemoKey, &fakeDemoService{})
+
+	r.GET("/demo2").Do().
+		AssertStatus(200).
+		AssertJSONPath("0.id", 7).
+		AssertJSONPath("0.name", "fake")
+
+	r.GET("/demo3").Do().
+		AssertStatus(200).
+		AssertBodyContains("hade-test")
+
+	r.GET("/demo/cache").Do().AssertStatus(200)
+	if !tc.Logs().Contains("cache get") {
+		t.Fatalf("cache log not captured: %v", tc.Logs().Lines())
+	}
+}
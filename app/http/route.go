@@ -1,8 +1,8 @@
 package http
 
 import (
-	"github.com/yefangyong/go-frame/app/http/module/demo"
 	"github.com/yefangyong/go-frame/framework/gin"
+	"github.com/yefangyong/go-frame/framework/middleware"
 	ginSwagger "github.com/yefangyong/go-frame/framework/middleware/gin-swagger"
 	"github.com/yefangyong/go-frame/framework/middleware/gin-swagger/swaggerFiles"
 	"github.com/yefangyong/go-frame/framework/middleware/static"
@@ -19,6 +19,7 @@ func Routes(r *gin.Engine) {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	//}
 
-	// demo相关路由注册
-	demo.Register(r)
+	// 健康检查
+	r.GET("/healthz", middleware.HealthLiveness())
+	r.GET("/readyz", middleware.HealthReadiness())
 }
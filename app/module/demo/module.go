@@ -0,0 +1,39 @@
+package demo
+
+import (
+	"time"
+
+	cmdDemo "github.com/yefangyong/go-frame/app/console/command/demo"
+	httpDemo "github.com/yefangyong/go-frame/app/http/module/demo"
+	"github.com/yefangyong/go-frame/app/provider/demo"
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/cobra"
+	"github.com/yefangyong/go-frame/framework/gin"
+	"github.com/yefangyong/go-frame/framework/module"
+)
+
+// Module demo 模块，包含 demo 服务、demo 接口、foo 命令和 foo 的分布式定时任务
+type Module struct{}
+
+func (m *Module) Name() string {
+	return "demo"
+}
+
+func (m *Module) Providers() []framework.ServiceProvider {
+	return []framework.ServiceProvider{&demo.DemoServiceProvider{}}
+}
+
+func (m *Module) Routes(r *gin.Engine) error {
+	return httpDemo.Register(r)
+}
+
+func (m *Module) Commands() []*cobra.Command {
+	return []*cobra.Command{cmdDemo.InitFoo()}
+}
+
+func (m *Module) CronJobs() []module.CronJob {
+	return []module.CronJob{
+		// 每个节点每5s调用一次Foo命令，抢占到了调度任务的节点将抢占锁持续挂载2s才释放
+		{Spec: "*/5 * * * * *", Command: cmdDemo.FooCommand, ServiceName: "foo_func_for_test", HoldTime: 2 * time.Second},
+	}
+}
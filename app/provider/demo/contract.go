@@ -1,5 +1,7 @@
 package demo
 
+import "github.com/yefangyong/go-frame/framework"
+
 const DemoKey = "demo"
 
 type Service interface {
@@ -10,3 +12,7 @@ type Student struct {
 	ID   int
 	Name string
 }
+
+func init() {
+	framework.RegisterContract(DemoKey, (*Service)(nil))
+}
@@ -65,3 +65,13 @@ func (c *Command) SetContainer(container framework.Container) {
 func (c *Command) GetContainer() framework.Container {
 	return c.Root().container
 }
+
+// Invoke 根据函数参数的接口类型从容器中获取服务，然后调用函数
+func (c *Command) Invoke(fn interface{}) error {
+	return c.GetContainer().Invoke(fn)
+}
+
+// Fill 为结构体中带有 hade:"inject" 标签的字段注入服务
+func (c *Command) Fill(target interface{}) error {
+	return c.GetContainer().Fill(target)
+}
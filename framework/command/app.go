@@ -10,6 +10,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -36,12 +37,12 @@ var appStartCommand = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 从Command中获取服务实例
 		container := cmd.GetContainer()
-
 		// 从服务容器中获取kernel的服务实例
 		kernelService := container.MustMake(contract.KernelKey).(contract.Kernel)
 
 		// 从kernel服务实例中获取引擎
 		core := kernelService.HttpEngine()
+		// 优先级从高到低为 --address 参数、环境变量 ADDRESS、配置 app.address（可以通过 --set app.address=:8080 或者环境变量 HADE_APP__ADDRESS 覆盖），都没有设置的时候为 :8888
 		if appAddress == "" {
 			envService := container.MustMake(contract.EnvKey).(contract.Env)
 			if envService.Get("ADDRESS") != "" {
@@ -142,6 +143,9 @@ func initAppCommand() *cobra.Command {
 	appCommand.AddCommand(appStateCommand)
 	appCommand.AddCommand(appStopCommand)
 	appCommand.AddCommand(appRestartCommand)
+	appHealthCommand.Flags().BoolVar(&appHealthLiveness, "liveness", false, "只执行存活检查")
+	appHealthCommand.Flags().StringVar(&appHealthFormat, "format", "table", "输出格式，支持 table 和 json")
+	appCommand.AddCommand(appHealthCommand)
 	return appCommand
 }
 
@@ -183,27 +187,43 @@ func startAppServer(server *http.Server, c framework.Container) error {
 	}()
 
 	// 当前的goroutine等待信号量
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	// 监控信号：SIGINT, SIGTERM, SIGQUIT
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	// 这里会阻塞当前goroutine等待信号
 	<-quit
 
-	closeWait := 5
-	configService := c.MustMake(contract.ConfigKey).(contract.Config)
-	if configService.IsExist("app.close_wait") {
-		closeWait = configService.GetInt("app.close_wait")
-	}
 	// 调用Server.Shutdown graceful结束
-	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Duration(closeWait)*time.Second)
+	closeWait := closeWaitDuration(c)
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), closeWait)
 	defer cancel()
-
+	var errs []string
 	if err := server.Shutdown(timeoutCtx); err != nil {
-		return err
+		errs = append(errs, "shutdown http server: "+err.Error())
+	}
+
+	// 关闭容器中的服务，释放连接池、日志文件等资源，http 服务关闭失败的时候也需要执行，使用单独的等待时间
+	containerCtx, containerCancel := context.WithTimeout(context.Background(), closeWait)
+	defer containerCancel()
+	if err := c.Shutdown(containerCtx); err != nil {
+		errs = append(errs, "shutdown container: "+err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
 	}
 	return nil
 }
 
+// closeWaitDuration 获取进程优雅关闭的最长等待时间，默认为5s，可以通过 app.close_wait 配置
+func closeWaitDuration(c framework.Container) time.Duration {
+	closeWait := 5
+	configService := c.MustMake(contract.ConfigKey).(contract.Config)
+	if configService.IsExist("app.close_wait") {
+		closeWait = configService.GetInt("app.close_wait")
+	}
+	return time.Duration(closeWait) * time.Second
+}
+
 // 获取启动的app的pid
 var appStateCommand = &cobra.Command{
 	Use:   "state",
@@ -275,12 +295,7 @@ func StopServe(pid int, container framework.Container) error {
 	}
 
 	// 检查pid是否存在
-	closeWait := 5
-	configService := container.MustMake(contract.ConfigKey).(contract.Config)
-	if configService.IsExist("app.close_wait") {
-		closeWait = configService.GetInt("app.close_wait")
-	}
-
+	closeWait := int(closeWaitDuration(container) / time.Second)
 	for i := 0; i < closeWait*2; i++ {
 		if util.CheckProcessExist(pid) == false {
 			break
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/yefangyong/go-frame/framework/cobra"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/util"
+)
+
+var appHealthLiveness = false
+var appHealthFormat = "table"
+
+// appHealthCommand 在命令行中执行和 /readyz 相同的健康检查
+var appHealthCommand = &cobra.Command{
+	Use:   "health",
+	Short: "执行健康检查，有检查项失败的时候返回错误",
+	RunE: func(c *cobra.Command, args []string) error {
+		container := c.GetContainer()
+		health := container.MustMake(contract.HealthKey).(contract.Health)
+
+		var report *contract.HealthReport
+		if appHealthLiveness {
+			report = health.Liveness(context.Background())
+		} else {
+			report = health.Readiness(context.Background())
+		}
+
+		switch appHealthFormat {
+		case "json":
+			out, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+		case "table":
+			ps := [][]string{{"检查项", "状态", "耗时", "错误"}}
+			for _, check := range report.Checks {
+				errMsg := "-"
+				if check.Error != "" {
+					errMsg = check.Error
+				}
+				ps = append(ps, []string{check.Name, check.Status, check.Duration, errMsg})
+			}
+			util.PrettyPrint(ps)
+			fmt.Println("status:", report.Status)
+		default:
+			return errors.New("unsupported format " + appHealthFormat + ", use table or json")
+		}
+
+		if report.Status != contract.HealthStatusUp {
+			return errors.New("health check failed")
+		}
+		return nil
+	},
+}
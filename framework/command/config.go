@@ -1,20 +1,68 @@
 package command
 
 import (
+	"errors"
 	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/kr/pretty"
 
+	"github.com/yefangyong/go-frame/framework"
 	"github.com/yefangyong/go-frame/framework/cobra"
 	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/config"
+	"github.com/yefangyong/go-frame/framework/util"
 )
 
 // initConfigCommand 获取配置相关的命令
 func initConfigCommand() *cobra.Command {
 	configCommand.AddCommand(configGetCommand)
+	configCommand.AddCommand(configValidateCommand)
+	configGetCommand.Flags().BoolVar(&configReveal, "reveal", false, "显示加密配置解密之后的值")
+	configCommand.AddCommand(configEncryptCommand)
+	configCommand.AddCommand(configDecryptCommand)
+	configListCommand.Flags().BoolVar(&configReveal, "reveal", false, "显示加密配置解密之后的值")
+	configCommand.AddCommand(configListCommand)
+	configDumpCommand.Flags().BoolVar(&configReveal, "reveal", false, "显示加密配置解密之后的值")
+	configDumpCommand.Flags().StringVar(&configDumpFormat, "format", "yaml", "输出格式，yaml 或者 json")
+	configCommand.AddCommand(configDumpCommand)
+	configDiffCommand.Flags().BoolVar(&configReveal, "reveal", false, "显示加密配置解密之后的值")
+	configCommand.AddCommand(configDiffCommand)
+	configSetCommand.Flags().BoolVar(&configSetPlain, "plain", false, "允许使用明文覆盖加密配置")
+	configCommand.AddCommand(configSetCommand)
+	configGenCommand.Flags().StringVar(&configGenOutput, "output", "", "生成代码的目录，默认为 app/config")
+	configCommand.AddCommand(configGenCommand)
+	configServeCommand.Flags().StringVar(&configServeFolder, "folder", "", "配置文件的目录，默认为 config/remote")
+	configServeCommand.Flags().StringVar(&configServeAddress, "address", ":8070", "服务监听的地址")
+	configCommand.AddCommand(configServeCommand)
 	return configCommand
 }
 
+// configSets 命令行中 --set key=value 设置的配置
+var configSets []string
+
+// initConfigOverride 为根命令增加 --set 参数并且检查格式
+// 配置服务创建的时候已经从命令行参数中读取 --set 覆盖配置，这里只是让所有命令都接受这个参数
+func initConfigOverride(root *cobra.Command) {
+	root.PersistentFlags().StringArrayVar(&configSets, "set", nil, "覆盖配置，格式为 key=value，优先级高于环境变量和配置文件，可以多次使用")
+	preRunE := root.PersistentPreRunE
+	root.PersistentPreRunE = func(command *cobra.Command, args []string) error {
+		for _, set := range configSets {
+			if _, _, err := config.ParseOverride(set); err != nil {
+				return err
+			}
+		}
+		if preRunE != nil {
+			return preRunE(command, args)
+		}
+		return nil
+	}
+}
+
 // 二级命令
 var configCommand = &cobra.Command{
 	Use:   "config",
@@ -27,6 +75,9 @@ var configCommand = &cobra.Command{
 	},
 }
 
+// configReveal 是否显示加密配置解密之后的值
+var configReveal bool
+
 // 获取配置命令
 var configGetCommand = &cobra.Command{
 	Use:   "get",
@@ -41,10 +92,202 @@ var configGetCommand = &cobra.Command{
 		key := args[0]
 		val := configService.Get(key)
 		if val == nil {
-			fmt.Println("配置路径 ", configService, " 不存在")
+			fmt.Println("配置路径 ", key, " 不存在")
 			return nil
 		}
+		if checker, ok := configService.(contract.ConfigSecretChecker); ok && !configReveal {
+			val = maskSecrets(checker, key, val)
+		}
 		fmt.Printf("%# v\n", pretty.Formatter(val))
+
+		// 打印每个配置项的来源文件
+		sourcer, ok := configService.(contract.ConfigSourcer)
+		if !ok {
+			return nil
+		}
+		sources := sourcer.Sources(key)
+		if source, ok := sources[key]; ok && len(sources) == 1 {
+			fmt.Println("来源:", relConfigPath(container, source))
+			return nil
+		}
+		paths := make([]string, 0, len(sources))
+		for path := range sources {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		ps := [][]string{{"配置项", "来源"}}
+		for _, path := range paths {
+			ps = append(ps, []string{path, relConfigPath(container, sources[path])})
+		}
+		util.PrettyPrint(ps)
+		return nil
+	},
+}
+
+// 加密配置命令
+var configEncryptCommand = &cobra.Command{
+	Use:     "encrypt",
+	Short:   "加密配置的值，结果可以直接写在配置文件中",
+	Example: "hade config encrypt root",
+	RunE: func(command *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			fmt.Println("参数错误")
+			return nil
+		}
+		keyring, err := newKeyring(command.GetContainer())
+		if err != nil {
+			return err
+		}
+		encrypted, err := keyring.Encrypt(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Println(encrypted)
+		return nil
+	},
+}
+
+// 解密配置命令
+var configDecryptCommand = &cobra.Command{
+	Use:     "decrypt",
+	Short:   "显示加密配置解密之后的值，参数为配置路径或者 enc(...) 格式的值",
+	Example: "hade config decrypt database.default.password",
+	RunE: func(command *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			fmt.Println("参数错误")
+			return nil
+		}
+		container := command.GetContainer()
+		if strings.HasPrefix(args[0], "enc(") {
+			keyring, err := newKeyring(container)
+			if err != nil {
+				return err
+			}
+			plain, err := keyring.Decrypt(args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Println(plain)
+			return nil
+		}
+
+		// 配置服务加载的时候已经解密
+		configService := container.MustMake(contract.ConfigKey).(contract.Config)
+		if !configService.IsExist(args[0]) {
+			fmt.Println("配置路径 ", args[0], " 不存在")
+			return nil
+		}
+		if checker, ok := configService.(contract.ConfigSecretChecker); ok && !checker.IsSecret(args[0]) {
+			fmt.Println("配置路径 ", args[0], " 不是加密的配置")
+			return nil
+		}
+		fmt.Println(configService.GetString(args[0]))
 		return nil
 	},
 }
+
+func newKeyring(container framework.Container) (*config.Keyring, error) {
+	return config.NewKeyring(container.MustMake(contract.EnvKey).(contract.Env))
+}
+
+// maskSecrets 返回将加密配置替换为 ****** 的值，不修改原来的值
+func maskSecrets(checker contract.ConfigSecretChecker, key string, val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			ret[k] = maskSecrets(checker, key+"."+k, child)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, child := range v {
+			ret[i] = maskSecrets(checker, key+"."+strconv.Itoa(i), child)
+		}
+		return ret
+	default:
+		if checker.IsSecret(key) {
+			return "******"
+		}
+		return val
+	}
+}
+
+// 检查配置命令
+var configValidateCommand = &cobra.Command{
+	Use:   "validate",
+	Short: "使用注册的配置结构检查所有配置",
+	RunE: func(command *cobra.Command, args []string) error {
+		container := command.GetContainer()
+		configService := container.MustMake(contract.ConfigKey).(contract.Config)
+
+		var errs config.ValidationErrors
+		for _, schema := range config.Schemas() {
+			for _, key := range schemaKeys(configService, schema.Key) {
+				target := reflect.New(reflect.TypeOf(schema.Target).Elem()).Interface()
+				err := config.Decode(key, configService.Get(key), target, true)
+				if err == nil {
+					continue
+				}
+				var fieldErrs config.ValidationErrors
+				if !errors.As(err, &fieldErrs) {
+					fieldErrs = config.ValidationErrors{{Key: key, Message: err.Error()}}
+				}
+				errs = append(errs, fieldErrs...)
+			}
+		}
+		if len(errs) == 0 {
+			fmt.Println("配置检查通过")
+			return nil
+		}
+
+		sourcer, _ := configService.(contract.ConfigSourcer)
+		ps := [][]string{{"位置", "配置项", "错误"}}
+		for _, e := range errs {
+			location := "-"
+			if sourcer != nil {
+				if file, line := sourcer.Locate(e.Key); file != "" {
+					location = relConfigPath(container, file)
+					if line > 0 {
+						location = fmt.Sprintf("%s:%d", location, line)
+					}
+				}
+			}
+			ps = append(ps, []string{location, e.Key, e.Message})
+		}
+		util.PrettyPrint(ps)
+		return fmt.Errorf("发现 %d 个配置错误", len(errs))
+	},
+}
+
+// schemaKeys 返回配置结构需要检查的配置路径，以 .* 结尾的路径展开为下面每一个子配置的路径
+func schemaKeys(configService contract.Config, key string) []string {
+	if !strings.HasSuffix(key, ".*") {
+		if !configService.IsExist(key) {
+			return nil
+		}
+		return []string{key}
+	}
+	parent := strings.TrimSuffix(key, ".*")
+	keys := []string{}
+	for name, val := range configService.GetStringMap(parent) {
+		if _, ok := val.(map[string]interface{}); ok {
+			keys = append(keys, parent+"."+name)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// relConfigPath 返回配置文件相对配置目录的路径，比如 base/database.yaml
+func relConfigPath(container framework.Container, file string) string {
+	appService := container.MustMake(contract.AppKey).(contract.App)
+	folder, err := filepath.Abs(appService.ConfigFolder())
+	if err != nil {
+		return file
+	}
+	if rel, err := filepath.Rel(folder, file); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return file
+}
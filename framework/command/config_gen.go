@@ -0,0 +1,303 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yefangyong/go-frame/framework/cobra"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/config"
+)
+
+// configGenHeader 生成的文件的第一行，只有带有这一行的文件才会被覆盖或者删除
+const configGenHeader = "// Code generated by hade config gen. DO NOT EDIT."
+
+// configGenOutput config gen 生成代码的目录
+var configGenOutput string
+
+// 根据配置文件生成配置结构命令
+var configGenCommand = &cobra.Command{
+	Use:   "gen",
+	Short: "根据配置文件生成带有 yaml 标签的配置结构和读取配置的方法",
+	Long: `读取配置目录中 base 和所有环境目录（development、testing、production 以及当前的 APP_ENV）的配置文件，为每个配置生成一个 <name>_gen.go 文件，默认输出到 app/config
+可以重复执行，只会覆盖或者删除生成的文件，手写的文件以及手写文件中已经定义的结构不会被修改`,
+	RunE: func(command *cobra.Command, args []string) error {
+		container := command.GetContainer()
+		appService := container.MustMake(contract.AppKey).(contract.App)
+		envService := container.MustMake(contract.EnvKey).(contract.Env)
+		output := configGenOutput
+		if output == "" {
+			output = filepath.Join(appService.AppFolder(), "config")
+		}
+
+		// 合并 base 和所有环境目录，生成的结构包含所有环境中出现的配置项
+		folders, err := configEnvFolders(appService.ConfigFolder(), envService.AppEnv())
+		if err != nil {
+			return err
+		}
+		maps, err := config.ReadFolders(folders...)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(output, 0755); err != nil {
+			return err
+		}
+		generated, handwritten, err := scanConfigGenFolder(output)
+		if err != nil {
+			return err
+		}
+
+		names := make([]string, 0, len(maps))
+		for name := range maps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		pkg := filepath.Base(output)
+		typeNames := map[string]string{}
+		for _, name := range names {
+			file := filepath.Join(output, name+"_gen.go")
+			typeName := goName(name) + "Config"
+			if other, ok := typeNames[typeName]; ok {
+				return fmt.Errorf("config %s and %s both generate type %s, rename one of them", other, name, typeName)
+			}
+			typeNames[typeName] = name
+			if handwritten[typeName] {
+				fmt.Println("跳过", name, "，", typeName, "已经在手写的文件中定义")
+				continue
+			}
+			if _, err := os.Stat(file); err == nil && !generated[file] {
+				fmt.Println("跳过", name, "，", file, "不是生成的文件")
+				continue
+			}
+			src, err := genConfigFile(pkg, name, typeName, maps[name].(map[string]interface{}))
+			if err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(file, src, 0644); err != nil {
+				return err
+			}
+			delete(generated, file)
+			fmt.Println("生成", file)
+		}
+
+		// 删除已经没有对应配置文件的生成文件
+		for file := range generated {
+			if err := os.Remove(file); err != nil {
+				return err
+			}
+			fmt.Println("删除", file)
+		}
+		return nil
+	},
+}
+
+// configEnvFolders 返回需要合并的配置目录，base 和存在的环境目录
+// 只包含 development、testing、production 和当前的 APP_ENV，config/remote 等其他目录不是环境的配置
+func configEnvFolders(configFolder, appEnv string) ([]string, error) {
+	envs := map[string]bool{contract.EnvDevelopment: true, contract.EnvTesting: true, contract.EnvProduction: true, appEnv: true}
+	folders := []string{filepath.Join(configFolder, "base")}
+	infos, err := ioutil.ReadDir(configFolder)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range infos {
+		if info.IsDir() && info.Name() != "base" && envs[info.Name()] {
+			folders = append(folders, filepath.Join(configFolder, info.Name()))
+		}
+	}
+	return folders, nil
+}
+
+var goTypePattern = regexp.MustCompile(`(?m)^type\s+(\w+)\s`)
+
+// scanConfigGenFolder 返回目录中生成的文件，以及手写的文件中定义的类型
+func scanConfigGenFolder(folder string) (map[string]bool, map[string]bool, error) {
+	generated, handwritten := map[string]bool{}, map[string]bool{}
+	files, err := filepath.Glob(filepath.Join(folder, "*.go"))
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		if bytes.HasPrefix(content, []byte(configGenHeader)) {
+			generated[file] = true
+			continue
+		}
+		for _, m := range goTypePattern.FindAllSubmatch(content, -1) {
+			handwritten[string(m[1])] = true
+		}
+	}
+	return generated, handwritten, nil
+}
+
+// genConfigFile 生成一个配置的结构和读取方法
+func genConfigFile(pkg, name, typeName string, values map[string]interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(configGenHeader + "\n\n")
+	buf.WriteString("package " + pkg + "\n\n")
+	if usesDuration(values) {
+		buf.WriteString("import (\n\t\"time\"\n\n\t\"github.com/yefangyong/go-frame/framework/contract\"\n)\n\n")
+	} else {
+		buf.WriteString("import \"github.com/yefangyong/go-frame/framework/contract\"\n\n")
+	}
+	fmt.Fprintf(&buf, "// %s 对应 %s 配置\n", typeName, name)
+	st, err := goStruct(name, values)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(&buf, "type %s %s\n\n", typeName, st)
+	fmt.Fprintf(&buf, "// Load%s 使用 Config.Load 读取 %s 配置\n", goName(name), name)
+	fmt.Fprintf(&buf, "func Load%s(c contract.Config) (*%s, error) {\n", goName(name), typeName)
+	fmt.Fprintf(&buf, "\tconf := &%s{}\n", typeName)
+	fmt.Fprintf(&buf, "\tif err := c.Load(%q, conf); err != nil {\n\t\treturn nil, err\n\t}\n\treturn conf, nil\n}\n", name)
+	return format.Source(buf.Bytes())
+}
+
+// goStruct 根据配置生成结构的定义，下级配置使用嵌套的结构，path 为这些配置项所在的配置路径
+// 不同的配置项转换为相同的字段名的时候返回错误，比如 foo_bar 和 foo-bar 都是 FooBar
+func goStruct(path string, values map[string]interface{}) (string, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	fields := map[string]string{}
+	var sb strings.Builder
+	sb.WriteString("struct {\n")
+	for _, key := range keys {
+		field := goName(key)
+		if other, ok := fields[field]; ok {
+			return "", fmt.Errorf("config %s.%s and %s.%s both generate field %s, rename one of them", path, other, path, key, field)
+		}
+		fields[field] = key
+		typ, err := goType(path+"."+key, values[key])
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "%s %s `yaml:\"%s\"`\n", field, typ, key)
+	}
+	sb.WriteString("}")
+	return sb.String(), nil
+}
+
+// goType 推断配置值对应的类型，比如 5s 为 time.Duration，整数为 int
+func goType(path string, val interface{}) (string, error) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return goStruct(path, v)
+	case []interface{}:
+		elem, err := goSliceElem(path, v)
+		return "[]" + elem, err
+	case bool:
+		return "bool", nil
+	case int, int64:
+		return "int", nil
+	case float64:
+		if v == float64(int64(v)) {
+			return "int", nil
+		}
+		return "float64", nil
+	case string:
+		if isDuration(v) {
+			return "time.Duration", nil
+		}
+		return "string", nil
+	default:
+		return "interface{}", nil
+	}
+}
+
+// goSliceElem 推断列表中元素的类型，元素的类型不同的时候为 interface{}，map 的元素合并所有的配置项
+func goSliceElem(path string, items []interface{}) (string, error) {
+	if len(items) == 0 {
+		return "interface{}", nil
+	}
+	if _, ok := items[0].(map[string]interface{}); ok {
+		merged := map[string]interface{}{}
+		for _, item := range items {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				return "interface{}", nil
+			}
+			for k, v := range m {
+				merged[k] = v
+			}
+		}
+		return goStruct(path+"[]", merged)
+	}
+	elem, err := goType(path+"[]", items[0])
+	if err != nil {
+		return "", err
+	}
+	for _, item := range items[1:] {
+		t, err := goType(path+"[]", item)
+		if err != nil {
+			return "", err
+		}
+		if t != elem {
+			if t == "float64" && elem == "int" || t == "int" && elem == "float64" {
+				elem = "float64"
+				continue
+			}
+			return "interface{}", nil
+		}
+	}
+	return elem, nil
+}
+
+func isDuration(s string) bool {
+	if _, err := time.ParseDuration(s); err != nil {
+		return false
+	}
+	return strings.IndexFunc(s, unicode.IsLetter) >= 0
+}
+
+func usesDuration(val interface{}) bool {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			if usesDuration(child) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if usesDuration(child) {
+				return true
+			}
+		}
+	case string:
+		return isDuration(v)
+	}
+	return false
+}
+
+// goName 将配置的 key 转换为导出的 Go 名称，比如 conn_max_idle 转换为 ConnMaxIdle
+func goName(key string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		rs := []rune(part)
+		rs[0] = unicode.ToUpper(rs[0])
+		sb.WriteString(string(rs))
+	}
+	name := sb.String()
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
@@ -0,0 +1,53 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigEnvFolders(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	for _, name := range []string{"base", "development", "production", "staging", "remote"} {
+		if err := os.MkdirAll(filepath.Join(folder, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// remote 等不是环境的目录不参与合并
+	folders, err := configEnvFolders(folder, "staging")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range folders {
+		names = append(names, filepath.Base(f))
+	}
+	if got := strings.Join(names, ","); got != "base,development,production,staging" {
+		t.Fatalf("folders = %s", got)
+	}
+}
+
+func TestGenConfigFile_FieldCollision(t *testing.T) {
+	values := map[string]interface{}{
+		"default": map[string]interface{}{"foo_bar": 1, "foo-bar": 2},
+	}
+	_, err := genConfigFile("config", "app", "AppConfig", values)
+	if err == nil || !strings.Contains(err.Error(), "app.default.foo-bar and app.default.foo_bar") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	src, err := genConfigFile("config", "app", "AppConfig", map[string]interface{}{"name": "hade", "timeout": "5s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "Timeout time.Duration `yaml:\"timeout\"`") {
+		t.Fatalf("unexpected source:\n%s", src)
+	}
+}
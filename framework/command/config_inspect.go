@@ -0,0 +1,204 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ghodss/yaml"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/cobra"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/app"
+	"github.com/yefangyong/go-frame/framework/provider/config"
+	"github.com/yefangyong/go-frame/framework/provider/env"
+	"github.com/yefangyong/go-frame/framework/util"
+)
+
+// configDumpFormat config dump 的输出格式
+var configDumpFormat string
+
+// 列出所有配置项命令
+var configListCommand = &cobra.Command{
+	Use:   "list",
+	Short: "列出所有配置项以及来源文件",
+	RunE: func(command *cobra.Command, args []string) error {
+		container := command.GetContainer()
+		configService := container.MustMake(contract.ConfigKey).(contract.Config)
+		values, err := flattenConfig(configService, configReveal)
+		if err != nil {
+			return err
+		}
+		sources := configService.(contract.ConfigSourcer).Sources("")
+		ps := [][]string{{"配置项", "值", "来源"}}
+		for _, key := range sortedKeys(values) {
+			ps = append(ps, []string{key, formatConfigValue(values[key]), relConfigPath(container, sources[key])})
+		}
+		util.PrettyPrint(ps)
+		return nil
+	},
+}
+
+// 输出合并之后的配置命令
+var configDumpCommand = &cobra.Command{
+	Use:   "dump",
+	Short: "输出所有配置文件合并之后的配置",
+	RunE: func(command *cobra.Command, args []string) error {
+		configService := command.GetContainer().MustMake(contract.ConfigKey).(contract.Config)
+		values, err := flattenConfig(configService, configReveal)
+		if err != nil {
+			return err
+		}
+		root := map[string]interface{}{}
+		for _, key := range sortedKeys(values) {
+			name := strings.SplitN(key, ".", 2)[0]
+			if _, ok := root[name]; !ok {
+				root[name] = configService.Get(name)
+				if checker, ok := configService.(contract.ConfigSecretChecker); ok && !configReveal {
+					root[name] = maskSecrets(checker, name, root[name])
+				}
+			}
+		}
+
+		var out []byte
+		switch configDumpFormat {
+		case "json":
+			out, err = json.MarshalIndent(root, "", "  ")
+			out = append(out, '\n')
+		case "yaml":
+			out, err = yaml.Marshal(root)
+		default:
+			return fmt.Errorf("unsupported format %s, use yaml or json", configDumpFormat)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(out))
+		return nil
+	},
+}
+
+// 比较两个环境的配置命令
+var configDiffCommand = &cobra.Command{
+	Use:     "diff",
+	Short:   "比较两个环境合并之后的配置",
+	Example: "hade config diff development production",
+	RunE: func(command *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			fmt.Println("参数错误")
+			return nil
+		}
+		container := command.GetContainer()
+		var values [2]map[string]interface{}
+		for i, envName := range args {
+			configService, err := loadEnvConfig(container, envName)
+			if err != nil {
+				return err
+			}
+			values[i], err = flattenConfig(configService, configReveal)
+			// 临时的配置服务可能监听了远程配置，用完之后关闭
+			_ = configService.Shutdown(context.Background())
+			if err != nil {
+				return err
+			}
+		}
+
+		keys := sortedKeys(values[0])
+		for key := range values[1] {
+			if _, ok := values[0][key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		ps := [][]string{{"配置项", args[0], args[1]}}
+		for _, key := range keys {
+			a, okA := values[0][key]
+			b, okB := values[1][key]
+			if okA && okB && formatConfigValue(a) == formatConfigValue(b) {
+				continue
+			}
+			ps = append(ps, []string{key, diffValue(a, okA), diffValue(b, okB)})
+		}
+		if len(ps) == 1 {
+			fmt.Println("两个环境的配置相同")
+			return nil
+		}
+		util.PrettyPrint(ps)
+		return nil
+	},
+}
+
+// loadEnvConfig 使用单独的容器加载某个环境的配置，不影响当前容器中的服务
+// 环境变量也按照这个环境读取，包括 .env.<envName>，返回的配置服务使用完需要调用 Shutdown 关闭
+func loadEnvConfig(container framework.Container, envName string) (*config.HadeConfig, error) {
+	appService := container.MustMake(contract.AppKey).(contract.App)
+	configFolder := appService.ConfigFolder()
+	if _, err := os.Stat(filepath.Join(configFolder, envName)); err != nil {
+		return nil, fmt.Errorf("config folder of env %s not exist", envName)
+	}
+
+	envContainer := framework.NewHadeContainer()
+	envContainer.Bind(&app.HadeAppProvider{BaseFolder: appService.BaseFolder()})
+	if err := envContainer.Bind(&env.HadeEnvProvider{AppEnv: envName}); err != nil {
+		return nil, err
+	}
+	envService := envContainer.MustMake(contract.EnvKey).(contract.Env)
+	folders := []string{filepath.Join(configFolder, "base"), filepath.Join(configFolder, envName)}
+	ins, err := config.NewHadeConfig(envContainer, folders, envService.All())
+	if err != nil {
+		return nil, err
+	}
+	return ins.(*config.HadeConfig), nil
+}
+
+// flattenConfig 返回所有配置项（叶子节点）的值，以完整的配置路径为 key，reveal 为 false 的时候隐藏加密配置
+func flattenConfig(configService contract.Config, reveal bool) (map[string]interface{}, error) {
+	sourcer, ok := configService.(contract.ConfigSourcer)
+	if !ok {
+		return nil, errors.New("config service can not list config keys")
+	}
+	checker, _ := configService.(contract.ConfigSecretChecker)
+	values := map[string]interface{}{}
+	for key := range sourcer.Sources("") {
+		val := configService.Get(key)
+		if checker != nil && !reveal && checker.IsSecret(key) {
+			val = "******"
+		}
+		values[key] = val
+	}
+	return values, nil
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatConfigValue 字符串直接输出，其他的值输出为 json
+func formatConfigValue(val interface{}) string {
+	if s, ok := val.(string); ok {
+		return s
+	}
+	bf, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprint(val)
+	}
+	return string(bf)
+}
+
+func diffValue(val interface{}, ok bool) string {
+	if !ok {
+		return "(不存在)"
+	}
+	return formatConfigValue(val)
+}
@@ -0,0 +1,54 @@
+package command
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/yefangyong/go-frame/framework/cobra"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/config"
+)
+
+var (
+	configServeFolder  string // 远程配置服务读取的目录
+	configServeAddress string // 远程配置服务监听的地址
+)
+
+// 在本地启动远程配置服务
+var configServeCommand = &cobra.Command{
+	Use:   "serve",
+	Short: "启动一个本地的远程配置服务，用于测试远程配置",
+	Long: `将目录中的配置文件作为远程配置提供给其他应用，目录中的文件变化之后会推送给长轮询中的应用
+应用设置环境变量 HADE_CONFIG_REMOTE 为服务的地址，比如 HADE_CONFIG_REMOTE=http://127.0.0.1:8070`,
+	RunE: func(command *cobra.Command, args []string) error {
+		folder := configServeFolder
+		if folder == "" {
+			appService := command.GetContainer().MustMake(contract.AppKey).(contract.App)
+			folder = filepath.Join(appService.ConfigFolder(), "remote")
+		}
+		source, err := config.NewFolderSource(folder)
+		if err != nil {
+			return err
+		}
+		files, err := source.Load()
+		if err != nil {
+			return err
+		}
+
+		server := config.NewRemoteServer()
+		for file, content := range files {
+			server.Set(file, content)
+		}
+		err = source.Watch(func(files map[string][]byte) {
+			for file, content := range files {
+				server.Set(file, content)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println("远程配置服务启动，地址：", configServeAddress, "，目录：", source.Path(""))
+		return http.ListenAndServe(configServeAddress, server)
+	},
+}
@@ -0,0 +1,103 @@
+package command
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yefangyong/go-frame/framework/cobra"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/config"
+)
+
+// configSetPlain 是否允许使用明文覆盖加密配置
+var configSetPlain bool
+
+// 修改配置命令
+var configSetCommand = &cobra.Command{
+	Use:     "set",
+	Short:   "修改配置文件中某个配置项的值，保留文件中的注释",
+	Long:    "修改当前环境目录中同名的 YAML 文件，文件不存在的时候创建，不修改 base 目录中所有环境共用的配置",
+	Example: "hade config set database.default.port 3307",
+	RunE: func(command *cobra.Command, args []string) error {
+		if len(args) != 2 {
+			fmt.Println("参数错误")
+			return nil
+		}
+		key, value := args[0], args[1]
+		path := strings.Split(key, ".")
+		if len(path) < 2 {
+			return fmt.Errorf("config key %s should be like file.key", key)
+		}
+
+		container := command.GetContainer()
+		appService := container.MustMake(contract.AppKey).(contract.App)
+		envService := container.MustMake(contract.EnvKey).(contract.Env)
+		configService := container.MustMake(contract.ConfigKey).(contract.Config)
+
+		// 加密配置不能被明文覆盖，除非明确指定 --plain
+		encrypted := strings.HasPrefix(value, "enc(") && strings.HasSuffix(value, ")")
+		if checker, ok := configService.(contract.ConfigSecretChecker); ok && checker.IsSecret(key) && !encrypted && !configSetPlain {
+			return fmt.Errorf("%s is encrypted, encrypt the new value with hade config encrypt, or pass --plain to store it in plain text", key)
+		}
+
+		var source string
+		if sourcer, ok := configService.(contract.ConfigSourcer); ok {
+			source = sourcer.Sources(key)[key]
+		}
+		file, err := configSetFile(appService.ConfigFolder(), envService.AppEnv(), key, source)
+		if err != nil {
+			return err
+		}
+
+		content, err := ioutil.ReadFile(file)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		content, err = config.SetYAMLValue(content, path[1:], value)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file, content, 0644); err != nil {
+			return err
+		}
+		fmt.Println("已修改", relConfigPath(container, file))
+		return nil
+	},
+}
+
+// configSetFile 返回 config set 需要修改的文件，总是当前环境目录中的文件，source 为配置项的来源，配置项不存在的时候为空
+// 配置项来自 base 目录的时候写入当前环境目录中的同名文件覆盖它，来源不是配置目录中的本地 YAML 文件的时候返回错误，比如环境变量或者配置目录之外的文件
+func configSetFile(configFolder, appEnv, key, source string) (string, error) {
+	folder, err := filepath.Abs(configFolder)
+	if err != nil {
+		return "", err
+	}
+	path := strings.Split(key, ".")
+	if source == "" {
+		return filepath.Join(folder, appEnv, path[0]+".yaml"), nil
+	}
+	if strings.Contains(source, "://") {
+		return "", fmt.Errorf("%s is defined in remote config %s, edit it in the config center", key, source)
+	}
+	file, err := filepath.Abs(source)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(folder, file)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is defined in %s, which is not a file in config folder %s", key, source, configFolder)
+	}
+	if ext := filepath.Ext(file); ext != ".yaml" && ext != ".yml" {
+		return "", fmt.Errorf("%s is defined in %s, only yaml file can be edited", key, rel)
+	}
+	if filepath.Dir(rel) != appEnv {
+		return filepath.Join(folder, appEnv, filepath.Base(file)), nil
+	}
+	return file, nil
+}
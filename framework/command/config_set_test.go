@@ -0,0 +1,42 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigSetFile(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+
+	file, err := configSetFile(folder, "dev", "app.name", "")
+	if err != nil || file != filepath.Join(folder, "dev", "app.yaml") {
+		t.Fatalf("configSetFile = %s, %v, want file of current env", file, err)
+	}
+	// base 中的配置写入当前环境目录的同名文件，不修改所有环境共用的配置
+	source := filepath.Join(folder, "base", "app.yml")
+	if file, err := configSetFile(folder, "dev", "app.name", source); err != nil || file != filepath.Join(folder, "dev", "app.yml") {
+		t.Fatalf("configSetFile = %s, %v, want file of current env", file, err)
+	}
+	source = filepath.Join(folder, "dev", "app.yml")
+	if file, err := configSetFile(folder, "dev", "app.name", source); err != nil || file != source {
+		t.Fatalf("configSetFile = %s, %v, want source file", file, err)
+	}
+
+	for _, source := range []string{
+		filepath.Join(filepath.Dir(folder), "app.yaml"),
+		"env:HADE_APP_NAME",
+		"set",
+		"http://127.0.0.1:8070/app.yaml",
+		filepath.Join(folder, "base", "app.toml"),
+	} {
+		if _, err := configSetFile(folder, "dev", "app.name", source); err == nil {
+			t.Fatalf("configSetFile should refuse source %s", source)
+		}
+	}
+}
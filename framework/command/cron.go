@@ -1,9 +1,11 @@
 package command
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"syscall"
@@ -54,7 +56,6 @@ var cronStartCommand = &cobra.Command{
 	RunE: func(c *cobra.Command, args []string) error {
 		// 获取容器
 		container := c.GetContainer()
-
 		// 获取容器中的App服务
 		appService := container.MustMake(contract.AppKey).(contract.App)
 
@@ -88,8 +89,7 @@ var cronStartCommand = &cobra.Command{
 			defer cntxt.Release()
 			fmt.Println("daemon start")
 			gspt.SetProcTitle("hade cron")
-			c.Root().Cron.Run()
-			return nil
+			return startCronServer(c)
 		}
 
 		// no deamon mode
@@ -101,11 +101,32 @@ var cronStartCommand = &cobra.Command{
 			return err
 		}
 		gspt.SetProcTitle("hade cron")
-		c.Root().Cron.Run()
-		return nil
+		return startCronServer(c)
 	},
 }
 
+// 启动定时任务，这个函数会将当前goroutine阻塞
+// 收到退出信号之后，等待正在执行的任务结束，并关闭容器中的服务
+func startCronServer(c *cobra.Command) error {
+	root := c.Root()
+	container := c.GetContainer()
+	root.Cron.Start()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
+	<-quit
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), closeWaitDuration(container))
+	defer cancel()
+
+	// 停止调度，并等待正在执行的任务结束
+	select {
+	case <-root.Cron.Stop().Done():
+	case <-timeoutCtx.Done():
+	}
+	return container.Shutdown(timeoutCtx)
+}
+
 var cronListCommand = &cobra.Command{
 	Use:   "list",
 	Short: "列出所有的定时任务",
@@ -211,7 +232,8 @@ var cronStopCommand = &cobra.Command{
 				return err
 			}
 
-			if err := syscall.Kill(pid, syscall.SIGTERM); err != nil {
+			// 发送退出信号，并等待进程释放资源之后退出
+			if err := StopServe(pid, container); err != nil {
 				return err
 			}
 
@@ -20,58 +20,39 @@ import (
 
 	"github.com/yefangyong/go-frame/framework"
 	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/config"
 )
 
+// 调试模式的配置，对应 app.yaml 中的 dev 配置
 type devConfig struct {
-	Port    string // 调试模式最终监听的端口
+	Port    string `yaml:"port" default:"8087"` // 调试模式最终监听的端口
 	Backend struct {
-		RefreshTime   int    // 调试模式后端更新时间，如果文件变更，等待3s才进行一次更新，能让频繁保存变更更为顺畅, 默认1s
-		Port          string // 后端监听端口
-		MonitorFolder string // 监听文件夹，默认为AppFolder
-	}
+		RefreshTime   int    `yaml:"refresh_time" default:"3" validate:"min=1"` // 调试模式后端更新时间，如果文件变更，等待3s才进行一次更新，能让频繁保存变更更为顺畅
+		Port          string `yaml:"port" default:"8072"`                       // 后端监听端口
+		MonitorFolder string `yaml:"monitor_folder"`                            // 监听文件夹，默认为AppFolder
+	} `yaml:"backend"`
 	Frontend struct { // 前端调试模式配置
-		Port string // 前端启动端口，默认8071
-	}
+		Port string `yaml:"port" default:"8071"` // 前端启动端口，默认8071
+	} `yaml:"frontend"`
 }
 
-func initDevConfig(container framework.Container) *devConfig {
-	devConfig := &devConfig{
-		Port: "8087",
-		Backend: struct {
-			RefreshTime   int
-			Port          string
-			MonitorFolder string
-		}{RefreshTime: 3, Port: "8072", MonitorFolder: ""},
-		Frontend: struct{ Port string }{Port: "8071"},
-	}
+func init() {
+	config.RegisterSchema("app.dev", (*devConfig)(nil))
+}
 
+func initDevConfig(container framework.Container) (*devConfig, error) {
+	devConfig := &devConfig{}
 	configService := container.MustMake(contract.ConfigKey).(contract.Config)
-
-	// 对每个配置项进行检查
-	if configService.IsExist("app.dev.port") {
-		devConfig.Port = configService.GetString("app.dev.port")
-	}
-
-	if configService.IsExist("app.dev.backend.port") {
-		devConfig.Port = configService.GetString("app.dev.backend.port")
-	}
-
-	if configService.IsExist("app.dev.backend.refresh_time") {
-		devConfig.Port = configService.GetString("app.dev.backend.refresh_time")
+	if err := configService.Load("app.dev", devConfig); err != nil {
+		return nil, err
 	}
 
 	// monitorFolder 默认使用目录服务的 AppFolder()
-	monitorFolder := configService.GetString("app.dev.backend.monitor_folder")
-	if monitorFolder == "" {
+	if devConfig.Backend.MonitorFolder == "" {
 		appService := container.MustMake(contract.AppKey).(contract.App)
 		devConfig.Backend.MonitorFolder = appService.AppFolder()
 	}
-
-	if configService.IsExist("app.dev.fronted.port") {
-		devConfig.Port = configService.GetString("app.dev.fronted.port")
-	}
-
-	return devConfig
+	return devConfig, nil
 }
 
 type Proxy struct {
@@ -81,11 +62,14 @@ type Proxy struct {
 }
 
 // 初始化一个Proxy
-func NewProxy(container framework.Container) *Proxy {
-	devConfig := initDevConfig(container)
+func NewProxy(container framework.Container) (*Proxy, error) {
+	devConfig, err := initDevConfig(container)
+	if err != nil {
+		return nil, err
+	}
 	return &Proxy{
 		devConfig: devConfig,
-	}
+	}, nil
 }
 
 // 重新启动一个 proxy 网关
@@ -330,7 +314,10 @@ var devBackendCommand = &cobra.Command{
 	Use:   "backend",
 	Short: "启动后端调试模式",
 	RunE: func(c *cobra.Command, args []string) error {
-		proxy := NewProxy(c.GetContainer())
+		proxy, err := NewProxy(c.GetContainer())
+		if err != nil {
+			return err
+		}
 		go proxy.monitorBackend()
 		if err := proxy.startProxy(false, true); err != nil {
 			return err
@@ -345,7 +332,10 @@ var devFrontendCommand = &cobra.Command{
 	Short: "前端调试模式",
 	RunE: func(c *cobra.Command, args []string) error {
 		// 启动前端服务
-		proxy := NewProxy(c.GetContainer())
+		proxy, err := NewProxy(c.GetContainer())
+		if err != nil {
+			return err
+		}
 		return proxy.startProxy(true, false)
 	},
 }
@@ -354,7 +344,10 @@ var devAllCommand = &cobra.Command{
 	Use:   "all",
 	Short: "同时启动前端和后端进行调试",
 	RunE: func(c *cobra.Command, args []string) error {
-		proxy := NewProxy(c.GetContainer())
+		proxy, err := NewProxy(c.GetContainer())
+		if err != nil {
+			return err
+		}
 		go proxy.monitorBackend()
 		if err := proxy.startProxy(true, true); err != nil {
 			return err
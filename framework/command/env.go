@@ -2,15 +2,20 @@ package command
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/yefangyong/go-frame/framework/cobra"
 	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/env"
 	"github.com/yefangyong/go-frame/framework/util"
 )
 
 // initEnvCommand 获取env相关的命令
 func initEnvCommand() *cobra.Command {
+	envListCommand.Flags().BoolVar(&envReveal, "reveal", false, "显示密码等敏感变量的值")
 	envCommand.AddCommand(envListCommand)
+	envCommand.AddCommand(envCheckCommand)
 	return envCommand
 }
 
@@ -19,27 +24,108 @@ var envCommand = &cobra.Command{
 	Use:   "env",
 	Short: "获取当前的App环境",
 	Run: func(c *cobra.Command, args []string) {
-		// 获取env环境
-		container := c.GetContainer()
-		envService := container.MustMake(contract.EnvKey).(contract.Env)
-		// 打印环境
-		fmt.Println("environment:", envService.AppEnv())
+		// 获取env环境并打印
+		err := c.Invoke(func(envService contract.Env) {
+			fmt.Println("environment:", envService.AppEnv())
+		})
+		if err != nil {
+			fmt.Println(err)
+		}
 	},
 }
 
+// envReveal 是否显示敏感变量的值
+var envReveal bool
+
 // envListCommand 获取所有的App环境变量
 var envListCommand = &cobra.Command{
 	Use:   "list",
-	Short: "获取所有的环境变量",
+	Short: "获取所有的环境变量，密码等敏感变量的值会被隐藏",
 	Run: func(c *cobra.Command, args []string) {
 		// 获取env环境
 		container := c.GetContainer()
 		envService := container.MustMake(contract.EnvKey).(contract.Env)
+		secrets := map[string]bool{}
+		if checker, ok := envService.(contract.EnvChecker); ok {
+			for _, v := range checker.Vars() {
+				secrets[v.Name] = v.Secret
+			}
+		}
+
 		envs := envService.All()
+		keys := make([]string, 0, len(envs))
+		for k := range envs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
 		outs := [][]string{}
-		for k, v := range envs {
+		for _, k := range keys {
+			v := envs[k]
+			if !envReveal && v != "" && (secrets[k] || env.IsSecretKey(k)) {
+				v = "******"
+			}
 			outs = append(outs, []string{k, v})
 		}
 		util.PrettyPrint(outs)
 	},
 }
+
+// envCheckCommand 检查应用声明的环境变量
+var envCheckCommand = &cobra.Command{
+	Use:   "check",
+	Short: "检查应用声明的环境变量是否设置以及格式是否正确",
+	Long:  "检查应用声明的环境变量，必须设置的变量缺失的时候其他命令直接退出并输出同样的报告",
+	RunE: func(c *cobra.Command, args []string) error {
+		envService := c.GetContainer().MustMake(contract.EnvKey).(contract.Env)
+		checker, ok := envService.(contract.EnvChecker)
+		if !ok || len(checker.Vars()) == 0 {
+			fmt.Println("没有声明环境变量")
+			return nil
+		}
+		problems := map[string]string{}
+		issues := checker.Check()
+		for _, issue := range issues {
+			problems[issue.Var.Name] = issue.Problem
+		}
+
+		outs := [][]string{{"变量", "类型", "必须", "状态", "说明"}}
+		for _, v := range checker.Vars() {
+			typ, required, status := v.Type, "否", "ok"
+			if typ == "" {
+				typ = contract.EnvTypeString
+			}
+			if v.Required {
+				required = "是"
+			}
+			if problem, ok := problems[v.Name]; ok {
+				status = problem
+			} else if !envService.IsExist(v.Name) {
+				status = "未设置"
+			}
+			outs = append(outs, []string{v.Name, typ, required, status, v.Description})
+		}
+		util.PrettyPrint(outs)
+		if len(issues) > 0 {
+			return &env.CheckError{Issues: issues}
+		}
+		return nil
+	},
+}
+
+// IsEnvCheck 判断命令行参数是否为 hade env check，args 不包含程序名
+// env 服务在声明的环境变量有问题的时候创建失败，hade env check 需要跳过检查才能输出完整的报告
+func IsEnvCheck(args []string) bool {
+	var cmds []string
+	for i := 0; i < len(args) && len(cmds) < 2; i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			// 全局参数的值可以和参数名分开写，比如 --set key=value
+			if !strings.Contains(arg, "=") && (arg == "--set" || arg == "--base_folder") {
+				i++
+			}
+			continue
+		}
+		cmds = append(cmds, arg)
+	}
+	return len(cmds) == 2 && cmds[0] == "env" && cmds[1] == "check"
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/cobra"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/app"
+	"github.com/yefangyong/go-frame/framework/provider/env"
+)
+
+func TestEnvCheckCommand_MissingRequired(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+
+	container := framework.NewHadeContainer()
+	if err := container.Bind(&app.HadeAppProvider{BaseFolder: folder}); err != nil {
+		t.Fatal(err)
+	}
+	// 必须设置的变量缺失的时候 env 服务创建失败
+	vars := []contract.EnvVar{{Name: "HADE_TEST_MISSING_VAR", Description: "测试变量", Required: true}}
+	err = container.Bind(&env.HadeEnvProvider{Vars: vars})
+	if err == nil || !strings.Contains(err.Error(), "HADE_TEST_MISSING_VAR: not set") {
+		t.Fatalf("err = %v, env provider should fail when required var is missing", err)
+	}
+	// 跳过检查之后由 env check 报告
+	args := []string{"--set", "app.name=test", "env", "check"}
+	if !IsEnvCheck(args) || IsEnvCheck([]string{"env", "list"}) || IsEnvCheck([]string{"app", "start"}) {
+		t.Fatal("IsEnvCheck returns wrong result")
+	}
+	if err := container.Bind(&env.HadeEnvProvider{Vars: vars, SkipCheck: IsEnvCheck(args)}); err != nil {
+		t.Fatal(err)
+	}
+
+	root := &cobra.Command{Use: "hade"}
+	root.SetContainer(container)
+	root.AddCommand(initEnvCommand())
+	root.SetArgs([]string{"env", "check"})
+	root.SilenceUsage = true
+	root.SilenceErrors = true
+	err = root.Execute()
+	if _, ok := err.(*env.CheckError); !ok {
+		t.Fatalf("err = %v, want *env.CheckError", err)
+	}
+	if !strings.Contains(err.Error(), "HADE_TEST_MISSING_VAR: not set") {
+		t.Fatalf("unexpected report: %v", err)
+	}
+}
@@ -3,6 +3,11 @@ package command
 import "github.com/yefangyong/go-frame/framework/cobra"
 
 func AddKernelCommands(root *cobra.Command) {
+	// base_folder 在 app 服务初始化的时候读取，这里只是让所有命令都接受这个参数
+	root.PersistentFlags().String("base_folder", "", "base_folder参数, 默认为当前路径")
+
+	// 所有命令都可以使用 --set 覆盖配置
+	initConfigOverride(root)
 
 	//绑定定时任务相关命令
 	root.AddCommand(InitCronCommand())
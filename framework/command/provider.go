@@ -1,10 +1,14 @@
 package command
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/yefangyong/go-frame/framework/contract"
@@ -20,7 +24,9 @@ import (
 // 初始化 provider 相关服务
 func initProviderCommand() *cobra.Command {
 	providerCommand.AddCommand(providerCreateCommand)
+	providerListCommand.Flags().StringVar(&providerListFormat, "format", "table", "输出格式，支持 table 和 json")
 	providerCommand.AddCommand(providerListCommand)
+	providerCommand.AddCommand(providerGraphCommand)
 	return providerCommand
 }
 
@@ -36,6 +42,9 @@ var providerCommand = &cobra.Command{
 	},
 }
 
+// provider list 的输出格式，支持 table 和 json
+var providerListFormat = "table"
+
 // 列出容器内所有的服务提供者
 var providerListCommand = &cobra.Command{
 	Use:   "list",
@@ -43,10 +52,105 @@ var providerListCommand = &cobra.Command{
 	RunE: func(command *cobra.Command, args []string) error {
 		container := command.GetContainer()
 		hadeContainer := container.(*framework.HadeContainer)
-		list := hadeContainer.ProviderList()
-		for _, line := range list {
-			println(line)
+		descs := hadeContainer.Describe()
+
+		switch providerListFormat {
+		case "json":
+			type item struct {
+				Key          string   `json:"key"`
+				Type         string   `json:"type"`
+				InstanceType string   `json:"instance_type,omitempty"`
+				Defer        bool     `json:"defer"`
+				Scoped       bool     `json:"scoped"`
+				Instantiated bool     `json:"instantiated"`
+				BootCost     string   `json:"boot_cost"`
+				RegisterCost string   `json:"register_cost"`
+				ResolvedBy   string   `json:"resolved_by,omitempty"`
+				Depends      []string `json:"depends,omitempty"`
+				Tags         []string `json:"tags,omitempty"`
+				Aliases      []string `json:"aliases,omitempty"`
+			}
+			items := make([]item, 0, len(descs))
+			for _, d := range descs {
+				items = append(items, item{
+					Key:          d.Key,
+					Type:         d.Type,
+					InstanceType: d.InstanceType,
+					Defer:        d.IsDefer,
+					Scoped:       d.IsScoped,
+					Instantiated: d.Instantiated,
+					BootCost:     d.BootCost.String(),
+					RegisterCost: d.RegisterCost.String(),
+					ResolvedBy:   d.ResolvedBy,
+					Depends:      d.Depends,
+					Tags:         d.Tags,
+					Aliases:      d.Aliases,
+				})
+			}
+			out, err := json.MarshalIndent(items, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(out))
+		case "table":
+			ps := [][]string{{"服务凭证", "类型", "加载方式", "已实例化", "Boot耗时", "Register耗时", "首次获取", "标签", "别名"}}
+			for _, d := range descs {
+				mode := "eager"
+				if d.IsScoped {
+					mode = "scoped"
+				} else if d.IsDefer {
+					mode = "defer"
+				}
+				instantiated, resolvedBy := "no", "-"
+				if d.Instantiated {
+					instantiated = "yes"
+				}
+				if d.ResolvedBy != "" {
+					resolvedBy = d.ResolvedBy
+				}
+				ps = append(ps, []string{d.Key, d.Type, mode, instantiated, d.BootCost.String(), d.RegisterCost.String(),
+					resolvedBy, joinOrDash(d.Tags), joinOrDash(d.Aliases)})
+			}
+			util.PrettyPrint(ps)
+		default:
+			return errors.New("unsupported format " + providerListFormat + ", use table or json")
+		}
+		return nil
+	},
+}
+
+// joinOrDash 排序之后使用逗号连接，为空的时候返回 -
+func joinOrDash(items []string) string {
+	if len(items) == 0 {
+		return "-"
+	}
+	items = append([]string{}, items...)
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// 按照启动顺序列出服务提供者，以及它们之间的依赖关系
+var providerGraphCommand = &cobra.Command{
+	Use:   "graph",
+	Short: "列出服务之间的依赖关系和启动顺序",
+	RunE: func(command *cobra.Command, args []string) error {
+		container := command.GetContainer()
+		hadeContainer := container.(*framework.HadeContainer)
+		order, err := hadeContainer.BootOrder()
+		if err != nil {
+			return err
+		}
+		graph := hadeContainer.ProviderGraph()
+		ps := [][]string{{"序号", "服务凭证", "依赖服务", "状态"}}
+		for i, key := range order {
+			deps, ok := graph[key]
+			state := "已注册"
+			if !ok {
+				state = "未注册"
+			}
+			ps = append(ps, []string{strconv.Itoa(i + 1), key, joinOrDash(deps), state})
 		}
+		util.PrettyPrint(ps)
 		return nil
 	},
 }
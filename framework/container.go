@@ -1,14 +1,17 @@
 package framework
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Container 是一个服务容器，提供绑定服务和获取服务的功能
 type Container interface {
-	// Bind 绑定一个服务提供者，如果关键字凭证已经存在，会进行替换
+	// Bind 绑定一个服务提供者，如果关键字凭证已经存在，会进行替换，被替换的实例会被关闭
 	Bind(provider ServiceProvider) error
 
 	// IsBind 关键字凭证是否已经绑定服务提供者
@@ -25,6 +28,19 @@ type Container interface {
 	// 它是根据服务提供者注册的启动函数和传递的params参数实例化出来的
 	// 这个函数在需要为不同参数启动不同实例的时候非常有用
 	MakeNew(key string, param []interface{}) (interface{}, error)
+
+	// Shutdown 按照实例化的相反顺序关闭容器中的服务，ctx 用于控制关闭的最长时间
+	Shutdown(ctx context.Context) error
+
+	// Invoke 根据函数 fn 每个参数的接口类型从容器中获取服务，然后调用 fn
+	// 接口类型需要通过 RegisterContract 注册，如果 fn 最后一个返回值为 error，会返回这个错误
+	Invoke(fn interface{}) error
+
+	// Fill 为结构体指针 target 中带有 hade:"inject" 标签的字段注入服务
+	Fill(target interface{}) error
+
+	// MakeTagged 获取某个标签下的所有服务，按照服务提供者的注册顺序返回
+	MakeTagged(tag string) ([]interface{}, error)
 }
 
 // HadeContainer 服务容器的具体实现
@@ -33,7 +49,23 @@ type HadeContainer struct {
 	providers map[string]ServiceProvider
 	// instance 存储具体的实例，key为字符串凭证
 	instances map[string]interface{}
-	// lock 用于锁住对容器的变更操作
+	// order 记录服务提供者的注册顺序
+	order []string
+	// pending 存储依赖还没有注册完成，等待实例化的非延迟服务凭证
+	pending []string
+	// booted 记录服务的实例化顺序，关闭的时候按照相反的顺序进行
+	booted []string
+	// versions 记录每个服务凭证被绑定的次数，用于丢弃服务提供者被替换之前开始的实例化结果
+	versions map[string]int
+	// building 存储正在实例化的服务，同一个服务同时只会有一个 goroutine 进行实例化
+	building map[string]*instanceCall
+	// tags 存储标签下的服务凭证，key为标签
+	tags map[string][]string
+	// aliases 存储服务凭证的别名，key为别名，value为实际的服务凭证
+	aliases map[string]string
+	// stats 存储服务实例化的统计信息
+	stats map[string]*instanceStat
+	// lock 用于锁住对容器的变更操作，实例化服务的过程中不会持有这个锁
 	lock sync.RWMutex
 	Container
 }
@@ -43,76 +75,435 @@ func NewHadeContainer() *HadeContainer {
 	return &HadeContainer{
 		providers: map[string]ServiceProvider{},
 		instances: map[string]interface{}{},
+		versions:  map[string]int{},
+		building:  map[string]*instanceCall{},
+		tags:      map[string][]string{},
+		aliases:   map[string]string{},
+		stats:     map[string]*instanceStat{},
 		lock:      sync.RWMutex{},
 	}
 }
 
+// ProviderList 返回服务容器中注册的关键字，按照注册顺序排列
 func (hade *HadeContainer) ProviderList() []string {
-	var ret []string
-	for _, provider := range hade.providers {
-		name := provider.Name()
-		line := fmt.Sprintf(name)
-		ret = append(ret, line)
-	}
+	hade.lock.RLock()
+	defer hade.lock.RUnlock()
+	ret := make([]string, 0, len(hade.order))
+	ret = append(ret, hade.order...)
 	return ret
 }
 
-// PrintProviders 输出服务容器中的注册的关键字
-func (hade *HadeContainer) PrintProviders() []string {
-	var ret []string
-	for _, provider := range hade.providers {
-		name := provider.Name()
-		line := fmt.Sprintf(name)
-		ret = append(ret, line)
+// Bind 绑定服务提供者，非延迟加载的服务会在它依赖的服务都注册之后立即实例化
+func (hade *HadeContainer) Bind(provider ServiceProvider) error {
+	key := provider.Name()
+	hade.lock.Lock()
+	if target, ok := hade.aliases[key]; ok {
+		hade.lock.Unlock()
+		return errors.New("contract " + key + " is already an alias of " + target)
 	}
-	return ret
+	old, exist := hade.providers[key]
+	hade.providers[key] = provider
+
+	// 检查新的服务提供者是否引入了循环依赖
+	if _, err := hade.sortProviders([]string{key}); err != nil {
+		if exist {
+			hade.providers[key] = old
+		} else {
+			delete(hade.providers, key)
+		}
+		hade.lock.Unlock()
+		return err
+	}
+	if !exist {
+		hade.order = append(hade.order, key)
+	}
+	// 替换服务提供者之后，之前的实例已经失效
+	hade.versions[key]++
+	oldIns, hasIns := hade.instances[key]
+	delete(hade.instances, key)
+	delete(hade.stats, key)
+	hade.removeBooted(key)
+	// 去掉等待中的旧服务提供者，避免重复实例化，替换为延迟加载的服务的时候也不再立即实例化
+	hade.removePending(key)
+	if provider.IsDefer() == false && !isScoped(provider) {
+		hade.pending = append(hade.pending, key)
+	}
+	// 去掉之前的服务提供者声明的标签，再加入新的服务提供者声明的标签
+	if t, ok := old.(ServiceProviderTagged); exist && ok {
+		for _, tag := range t.Tags() {
+			hade.untag(tag, key)
+		}
+	}
+	if t, ok := provider.(ServiceProviderTagged); ok {
+		for _, tag := range t.Tags() {
+			hade.tag(tag, key)
+		}
+	}
+	hade.lock.Unlock()
+
+	// 关闭被替换的实例，释放它持有的连接、文件等资源
+	if hasIns {
+		if errs := shutdownInstance(context.Background(), key, oldIns, old); len(errs) > 0 {
+			return errors.New("shutdown replaced instance: " + strings.Join(errs, "; "))
+		}
+	}
+	// 这次绑定触发实例化的所有服务的错误都会返回，包括等待这个服务的其他服务
+	return hade.bootPending()
 }
 
-func (hade *HadeContainer) Bind(provider ServiceProvider) error {
+// Unbind 解除服务凭证的绑定，已经实例化的服务会被关闭，服务凭证也会从所有标签中去掉
+func (hade *HadeContainer) Unbind(key string) error {
 	hade.lock.Lock()
-	key := provider.Name()
-	hade.providers[key] = provider
+	sp, exist := hade.providers[key]
+	if !exist {
+		hade.lock.Unlock()
+		return nil
+	}
+	delete(hade.providers, key)
+	for i, k := range hade.order {
+		if k == key {
+			hade.order = append(hade.order[:i:i], hade.order[i+1:]...)
+			break
+		}
+	}
+	hade.removePending(key)
+	for tag := range hade.tags {
+		hade.untag(tag, key)
+	}
+	hade.versions[key]++
+	ins, hasIns := hade.instances[key]
+	delete(hade.instances, key)
+	delete(hade.stats, key)
+	hade.removeBooted(key)
 	hade.lock.Unlock()
-	if provider.IsDefer() == false {
-		if err := provider.Boot(hade); err != nil {
-			return err
+
+	if hasIns {
+		if errs := shutdownInstance(context.Background(), key, ins, sp); len(errs) > 0 {
+			return errors.New("shutdown unbound instance: " + strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// removeBooted 从实例化顺序中去掉某个服务凭证，调用的时候需要持有锁
+func (hade *HadeContainer) removeBooted(key string) {
+	for i, k := range hade.booted {
+		if k == key {
+			hade.booted = append(hade.booted[:i:i], hade.booted[i+1:]...)
+			return
+		}
+	}
+}
+
+// Tag 将服务凭证加入到标签中，一个服务凭证可以有多个标签
+func (hade *HadeContainer) Tag(tag string, keys ...string) {
+	hade.lock.Lock()
+	defer hade.lock.Unlock()
+	for _, key := range keys {
+		hade.tag(tag, key)
+	}
+}
+
+// untag 将服务凭证从标签中去掉，调用的时候需要持有锁
+func (hade *HadeContainer) untag(tag string, key string) {
+	keys := hade.tags[tag]
+	for i, k := range keys {
+		if k == key {
+			hade.tags[tag] = append(keys[:i:i], keys[i+1:]...)
+			break
+		}
+	}
+	if len(hade.tags[tag]) == 0 {
+		delete(hade.tags, tag)
+	}
+}
+
+func (hade *HadeContainer) tag(tag string, key string) {
+	for _, k := range hade.tags[tag] {
+		if k == key {
+			return
 		}
+	}
+	hade.tags[tag] = append(hade.tags[tag], key)
+}
 
-		params := provider.Params(hade)
-		method := provider.Register(hade)
-		instance, err := method(params...)
+// Alias 为服务凭证设置别名，Make 别名的时候会获取到实际服务凭证对应的服务
+func (hade *HadeContainer) Alias(alias string, key string) error {
+	hade.lock.Lock()
+	defer hade.lock.Unlock()
+	if _, ok := hade.providers[alias]; ok {
+		return errors.New("alias " + alias + " conflicts with a bound contract")
+	}
+	// 检查别名是否会形成环
+	for k := key; ; {
+		if k == alias {
+			return errors.New("alias cycle: " + alias + " -> " + key)
+		}
+		next, ok := hade.aliases[k]
+		if !ok {
+			break
+		}
+		k = next
+	}
+	hade.aliases[alias] = key
+	return nil
+}
+
+// resolveAlias 获取别名对应的实际服务凭证，调用的时候需要持有锁
+func (hade *HadeContainer) resolveAlias(key string) string {
+	for {
+		target, ok := hade.aliases[key]
+		if !ok {
+			return key
+		}
+		key = target
+	}
+}
+
+// Tags 返回所有的标签和标签下的服务凭证
+func (hade *HadeContainer) Tags() map[string][]string {
+	hade.lock.RLock()
+	defer hade.lock.RUnlock()
+	tags := make(map[string][]string, len(hade.tags))
+	for tag, keys := range hade.tags {
+		tags[tag] = append([]string{}, keys...)
+	}
+	return tags
+}
+
+// Aliases 返回所有的别名，key为别名，value为实际的服务凭证
+func (hade *HadeContainer) Aliases() map[string]string {
+	hade.lock.RLock()
+	defer hade.lock.RUnlock()
+	aliases := make(map[string]string, len(hade.aliases))
+	for alias, key := range hade.aliases {
+		aliases[alias] = key
+	}
+	return aliases
+}
+
+// taggedKeys 获取标签下的服务凭证，按照服务提供者的注册顺序排序
+func (hade *HadeContainer) taggedKeys(tag string) []string {
+	hade.lock.RLock()
+	defer hade.lock.RUnlock()
+	index := make(map[string]int, len(hade.order))
+	for i, key := range hade.order {
+		index[key] = i
+	}
+	keys := append([]string{}, hade.tags[tag]...)
+	sort.SliceStable(keys, func(i, j int) bool {
+		ki, oki := index[hade.resolveAlias(keys[i])]
+		kj, okj := index[hade.resolveAlias(keys[j])]
+		// 还没有注册的服务排在最后
+		if oki != okj {
+			return oki
+		}
+		return ki < kj
+	})
+	return keys
+}
+
+func (hade *HadeContainer) MakeTagged(tag string) ([]interface{}, error) {
+	return makeTagged(hade, hade.taggedKeys(tag))
+}
+
+// makeTagged 在容器 c 中依次获取服务
+func makeTagged(c Container, keys []string) ([]interface{}, error) {
+	instances := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		ins, err := c.Make(key)
 		if err != nil {
-			return errors.New(err.Error())
+			return nil, err
+		}
+		instances = append(instances, ins)
+	}
+	return instances, nil
+}
+
+// bootPending 实例化所有依赖已经注册完成的非延迟服务，有多个服务实例化失败的时候合并所有的错误
+func (hade *HadeContainer) bootPending() error {
+	hade.lock.Lock()
+	var ready, waiting []string
+	for _, key := range hade.pending {
+		if len(hade.missingDepends(key)) == 0 {
+			ready = append(ready, key)
+		} else {
+			waiting = append(waiting, key)
+		}
+	}
+	hade.pending = waiting
+	hade.lock.Unlock()
+
+	var errs []error
+	for _, key := range ready {
+		if _, err := hade.make(key, nil, false, "bind", nil); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// removePending 从等待实例化的服务中去掉 key，调用方需要持有写锁
+func (hade *HadeContainer) removePending(key string) {
+	for i, k := range hade.pending {
+		if k == key {
+			hade.pending = append(hade.pending[:i:i], hade.pending[i+1:]...)
+			return
 		}
-		hade.instances[key] = instance
+	}
+}
+
+// missingDepends 返回某个服务直接或者间接依赖，但是还没有注册的服务凭证
+func (hade *HadeContainer) missingDepends(key string) []string {
+	var missing []string
+	visited := map[string]bool{}
+	var visit func(key string)
+	visit = func(key string) {
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		sp, ok := hade.providers[hade.resolveAlias(key)]
+		if !ok {
+			missing = append(missing, key)
+			return
+		}
+		for _, dep := range providerDepends(sp) {
+			visit(dep)
+		}
+	}
+	visit(key)
+	return missing
+}
+
+// sortProviders 按照依赖关系对服务凭证进行拓扑排序，被依赖的服务排在前面
+// 如果存在循环依赖，返回包含完整依赖路径的错误
+func (hade *HadeContainer) sortProviders(keys []string) ([]string, error) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	states := map[string]int{}
+	var sorted, path []string
+	var visit func(key string) error
+	visit = func(key string) error {
+		switch states[key] {
+		case visited:
+			return nil
+		case visiting:
+			for i, k := range path {
+				if k == key {
+					cycle := append(append([]string{}, path[i:]...), key)
+					return errors.New("provider dependency cycle: " + strings.Join(cycle, " -> "))
+				}
+			}
+		}
+		states[key] = visiting
+		path = append(path, key)
+		if sp, ok := hade.providers[key]; ok {
+			for _, dep := range providerDepends(sp) {
+				if err := visit(hade.resolveAlias(dep)); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		states[key] = visited
+		sorted = append(sorted, key)
+		return nil
+	}
+	for _, key := range keys {
+		if err := visit(key); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// NewScope 创建一个子作用域，作用域内的服务在子作用域中实例化，其他服务从当前容器中获取
+func (hade *HadeContainer) NewScope() *HadeScope {
+	return &HadeScope{parent: hade}
+}
+
+// scopedProvider 获取作用域内服务的服务提供者，如果不是作用域内的服务，返回nil
+func (hade *HadeContainer) scopedProvider(key string) ServiceProvider {
+	hade.lock.RLock()
+	defer hade.lock.RUnlock()
+	if sp, ok := hade.providers[hade.resolveAlias(key)]; ok && isScoped(sp) {
+		return sp
 	}
 	return nil
 }
 
+// BootOrder 返回所有注册的服务按照依赖关系排序之后的实例化顺序
+func (hade *HadeContainer) BootOrder() ([]string, error) {
+	hade.lock.RLock()
+	defer hade.lock.RUnlock()
+	return hade.sortProviders(hade.order)
+}
+
+// ProviderGraph 返回服务之间的依赖关系，key为服务凭证，value为它依赖的服务凭证
+func (hade *HadeContainer) ProviderGraph() map[string][]string {
+	hade.lock.RLock()
+	defer hade.lock.RUnlock()
+	graph := make(map[string][]string, len(hade.providers))
+	for key, sp := range hade.providers {
+		graph[key] = providerDepends(sp)
+	}
+	return graph
+}
+
 // IsBind 是否绑定服务
 func (hade *HadeContainer) IsBind(key string) bool {
 	return hade.findServiceProvider(key) != nil
 }
 
+// Provider 获取服务凭证（或别名）对应的服务提供者，没有绑定的时候返回 nil
+func (hade *HadeContainer) Provider(key string) ServiceProvider {
+	return hade.findServiceProvider(key)
+}
+
 func (hade *HadeContainer) findServiceProvider(key string) ServiceProvider {
-	//hade.lock.RLock()
-	//defer hade.lock.RUnlock()
-	if sp, ok := hade.providers[key]; ok {
+	hade.lock.RLock()
+	defer hade.lock.RUnlock()
+	if sp, ok := hade.providers[hade.resolveAlias(key)]; ok {
 		return sp
 	}
 	return nil
 }
 
-func (hade *HadeContainer) newInstance(provider ServiceProvider, params []interface{}) (interface{}, error) {
-	if err := provider.Boot(hade); err != nil {
+// newInstance 在实例化 call 的过程中使用服务提供者创建实例，服务提供者通过传入的容器获取的服务属于这次实例化
+func (hade *HadeContainer) newInstance(provider ServiceProvider, params []interface{}, stat *instanceStat, call *instanceCall) (interface{}, error) {
+	return buildInstance(withCall(hade, call), provider, params, stat)
+}
+
+// buildInstance 使用服务提供者在容器 c 中实例化一个服务，stat 不为空的时候记录各个阶段的耗时
+func buildInstance(c Container, provider ServiceProvider, params []interface{}, stat *instanceStat) (interface{}, error) {
+	start := time.Now()
+	if err := provider.Boot(c); err != nil {
 		return nil, err
 	}
+	booted := time.Now()
 	if params == nil {
-		params = provider.Params(hade)
+		params = provider.Params(c)
 	}
 
-	method := provider.Register(hade)
+	method := provider.Register(c)
 	ins, err := method(params...)
+	if stat != nil {
+		stat.bootCost = booted.Sub(start)
+		stat.registerCost = time.Since(booted)
+	}
 	if err != nil {
 		return nil, errors.New(err.Error())
 	}
@@ -120,11 +511,11 @@ func (hade *HadeContainer) newInstance(provider ServiceProvider, params []interf
 }
 
 func (hade *HadeContainer) Make(key string) (interface{}, error) {
-	return hade.make(key, nil, false)
+	return hade.make(key, nil, false, "", nil)
 }
 
 func (hade *HadeContainer) MustMake(key string) interface{} {
-	ins, err := hade.make(key, nil, false)
+	ins, err := hade.make(key, nil, false, "", nil)
 	if err != nil {
 		panic(err)
 	}
@@ -132,31 +523,160 @@ func (hade *HadeContainer) MustMake(key string) interface{} {
 }
 
 func (hade *HadeContainer) MakeNew(key string, params []interface{}) (interface{}, error) {
-	return hade.make(key, params, true)
+	return hade.make(key, params, true, "", nil)
+}
+
+func (hade *HadeContainer) makeFrom(call *instanceCall, key string, params []interface{}, forceNew bool) (interface{}, error) {
+	return hade.make(key, params, forceNew, "", call)
+}
+
+func (hade *HadeContainer) Invoke(fn interface{}) error {
+	return invoke(hade, fn)
+}
+
+func (hade *HadeContainer) Fill(target interface{}) error {
+	return fill(hade, target)
+}
+
+// makeDepends 在实例化 call 的过程中实例化服务提供者声明依赖的所有服务
+func (hade *HadeContainer) makeDepends(key string, sp ServiceProvider, call *instanceCall) error {
+	for _, dep := range providerDepends(sp) {
+		if _, err := hade.make(dep, nil, false, "depends of "+key, call); err != nil {
+			return errors.New("contract " + key + " depends on " + dep + ": " + err.Error())
+		}
+	}
+	return nil
 }
 
 // 真正实例化一个服务
-func (hade *HadeContainer) make(key string, params []interface{}, forceNew bool) (interface{}, error) {
+// 同一个服务同时只有一个 goroutine 在实例化，其他 goroutine 等待实例化的结果
+// 实例化的过程中不持有容器的锁，服务提供者在 Boot、Params 中可以继续 Make 其他服务
+// from 记录是谁触发了实例化，为空的时候使用容器外部调用者的代码位置
+// parent 为正在进行的实例化，服务提供者在实例化过程中通过传入的容器 Make 的时候不为空，用于发现互相等待
+func (hade *HadeContainer) make(key string, params []interface{}, forceNew bool, from string, parent *instanceCall) (interface{}, error) {
+	parent = activeCall(parent)
 	hade.lock.RLock()
-	defer hade.lock.RUnlock()
+	key = hade.resolveAlias(key)
+	hade.lock.RUnlock()
 	sp := hade.findServiceProvider(key)
 	if sp == nil {
 		return nil, errors.New("contract " + key + " have not register")
 	}
+	if isScoped(sp) {
+		return nil, errors.New("contract " + key + " is scoped, make it from a scope")
+	}
 	if forceNew {
-		return hade.newInstance(sp, params)
+		if err := hade.makeDepends(key, sp, parent); err != nil {
+			return nil, err
+		}
+		return hade.newInstance(sp, params, nil, parent)
 	}
 
 	// 不需要强制重新实例化，如果容器中已经实例化了，那么直接返回即可
+	hade.lock.RLock()
+	ins, ok := hade.instances[key]
+	hade.lock.RUnlock()
+	if ok {
+		return ins, nil
+	}
+
+	hade.lock.Lock()
 	if ins, ok := hade.instances[key]; ok {
+		hade.lock.Unlock()
 		return ins, nil
 	}
+	if call, ok := hade.building[key]; ok {
+		hade.lock.Unlock()
+		// 已经有 goroutine 在实例化这个服务，等待它的结果，等待会导致互相等待的时候返回错误
+		if err := waitCall(parent, call); err != nil {
+			return nil, err
+		}
+		return call.ins, call.err
+	}
+	call := &instanceCall{done: make(chan struct{}), key: key, parent: parent, version: hade.versions[key]}
+	hade.building[key] = call
+	beginCall(parent, call)
+	hade.lock.Unlock()
 
-	// 容器中还未实例化，则进行一次实例化
-	inst, err := hade.newInstance(sp, nil)
-	if err != nil {
-		return nil, err
+	if from == "" {
+		from = callerLocation()
+	}
+	stat := &instanceStat{resolvedBy: from}
+	completed := false
+	defer func() {
+		// 服务提供者在实例化过程中 panic，通知等待的 goroutine 实例化失败，panic 继续向上传递
+		if !completed {
+			call.ins, call.err = nil, errors.New("contract "+key+" panicked during instantiation")
+		}
+		hade.lock.Lock()
+		// 实例化过程中服务提供者被替换了，这次实例化的结果不再保存
+		stale := call.err == nil && hade.versions[key] != call.version
+		if call.err == nil && !stale {
+			hade.instances[key] = call.ins
+			hade.booted = append(hade.booted, key)
+			hade.stats[key] = stat
+		}
+		delete(hade.building, key)
+		hade.lock.Unlock()
+		endCall(parent)
+		close(call.done)
+		// 丢弃的实例不会再被关闭，这里释放它持有的资源
+		if stale {
+			shutdownInstance(context.Background(), key, call.ins, sp)
+		}
+	}()
+
+	// 先实例化依赖的服务，保证依赖的服务先启动，然后实例化自己
+	if call.err = hade.makeDepends(key, sp, call); call.err == nil {
+		call.ins, call.err = hade.newInstance(sp, nil, stat, call)
+	}
+	completed = true
+	return call.ins, call.err
+}
+
+// shutdownInstance 关闭服务实例和它的服务提供者，实现了 Shutdowner 接口才会被调用
+func shutdownInstance(ctx context.Context, key string, ins interface{}, sp ServiceProvider) []string {
+	var errs []string
+	if s, ok := ins.(Shutdowner); ok {
+		if err := s.Shutdown(ctx); err != nil {
+			errs = append(errs, key+": "+err.Error())
+		}
+	}
+	if s, ok := sp.(Shutdowner); ok {
+		if err := s.Shutdown(ctx); err != nil {
+			errs = append(errs, key+": "+err.Error())
+		}
 	}
-	hade.instances[key] = inst
-	return inst, nil
+	return errs
+}
+
+// Shutdown 按照实例化的相反顺序关闭服务，服务实例和服务提供者实现了 Shutdowner 接口才会被调用
+// 如果 ctx 已经超时，剩余的服务不再关闭
+func (hade *HadeContainer) Shutdown(ctx context.Context) error {
+	hade.lock.Lock()
+	booted := hade.booted
+	hade.booted = nil
+	hade.lock.Unlock()
+
+	var errs []string
+	for i := len(booted) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, "shutdown aborted: "+err.Error())
+			break
+		}
+		key := booted[i]
+		hade.lock.Lock()
+		ins, ok := hade.instances[key]
+		delete(hade.instances, key)
+		sp := hade.providers[key]
+		hade.lock.Unlock()
+		if !ok {
+			continue
+		}
+		errs = append(errs, shutdownInstance(ctx, key, ins, sp)...)
+	}
+	if len(errs) > 0 {
+		return errors.New("container shutdown error: " + strings.Join(errs, "; "))
+	}
+	return nil
 }
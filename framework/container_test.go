@@ -0,0 +1,531 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testProvider 用于测试的服务提供者，实例化的时候记录实例化顺序
+type testProvider struct {
+	name    string
+	isDefer bool
+	depends []string
+	booted  *[]string
+}
+
+func (p *testProvider) Register(c Container) NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		if p.booted != nil {
+			*p.booted = append(*p.booted, p.name)
+		}
+		return p.name, nil
+	}
+}
+
+func (p *testProvider) Boot(c Container) error {
+	return nil
+}
+
+func (p *testProvider) IsDefer() bool {
+	return p.isDefer
+}
+
+func (p *testProvider) Params(c Container) []interface{} {
+	return nil
+}
+
+func (p *testProvider) Name() string {
+	return p.name
+}
+
+func (p *testProvider) Depends() []string {
+	return p.depends
+}
+
+// shutdownProvider 在关闭的时候记录关闭顺序
+type shutdownProvider struct {
+	testProvider
+	closed *[]string
+}
+
+func (p *shutdownProvider) Shutdown(ctx context.Context) error {
+	*p.closed = append(*p.closed, p.name)
+	return nil
+}
+
+func TestHadeContainer_BindOutOfOrder(t *testing.T) {
+	var booted []string
+	c := NewHadeContainer()
+	if err := c.Bind(&testProvider{name: "log", depends: []string{"config"}, booted: &booted}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Bind(&testProvider{name: "config", depends: []string{"app"}, booted: &booted}); err != nil {
+		t.Fatal(err)
+	}
+	if len(booted) != 0 {
+		t.Fatalf("providers booted before dependencies registered: %v", booted)
+	}
+	if err := c.Bind(&testProvider{name: "app", booted: &booted}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(booted, ","); got != "app,config,log" {
+		t.Fatalf("boot order = %s, want app,config,log", got)
+	}
+
+	order, err := c.BootOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(order, ","); got != "app,config,log" {
+		t.Fatalf("BootOrder() = %s, want app,config,log", got)
+	}
+}
+
+func TestHadeContainer_BindCycle(t *testing.T) {
+	c := NewHadeContainer()
+	_ = c.Bind(&testProvider{name: "a", isDefer: true, depends: []string{"b"}})
+	_ = c.Bind(&testProvider{name: "b", isDefer: true, depends: []string{"c"}})
+	err := c.Bind(&testProvider{name: "c", isDefer: true, depends: []string{"a"}})
+	if err == nil {
+		t.Fatal("expect cycle error")
+	}
+	if !strings.Contains(err.Error(), "c -> a -> b -> c") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.IsBind("c") {
+		t.Fatal("provider with cycle should not be bound")
+	}
+}
+
+func TestHadeContainer_MakeMissingDepend(t *testing.T) {
+	c := NewHadeContainer()
+	_ = c.Bind(&testProvider{name: "orm", isDefer: true, depends: []string{"config"}})
+	_, err := c.Make("orm")
+	if err == nil || !strings.Contains(err.Error(), "contract orm depends on config") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestHadeContainer_Shutdown(t *testing.T) {
+	var closed []string
+	c := NewHadeContainer()
+	_ = c.Bind(&shutdownProvider{testProvider: testProvider{name: "app"}, closed: &closed})
+	_ = c.Bind(&shutdownProvider{testProvider: testProvider{name: "config", depends: []string{"app"}}, closed: &closed})
+	_ = c.Bind(&shutdownProvider{testProvider: testProvider{name: "orm", isDefer: true, depends: []string{"config"}}, closed: &closed})
+	_ = c.Bind(&shutdownProvider{testProvider: testProvider{name: "redis", isDefer: true}, closed: &closed})
+	c.MustMake("orm")
+
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(closed, ","); got != "orm,config,app" {
+		t.Fatalf("shutdown order = %s, want orm,config,app", got)
+	}
+}
+
+// failProvider 实例化的时候返回错误
+type failProvider struct {
+	testProvider
+}
+
+func (p *failProvider) Register(c Container) NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		return nil, errors.New(p.name + " failed")
+	}
+}
+
+func TestHadeContainer_Rebind(t *testing.T) {
+	var closed []string
+	c := NewHadeContainer()
+	_ = c.Bind(&shutdownProvider{testProvider: testProvider{name: "orm"}, closed: &closed})
+	_ = c.Bind(&taggedProvider{testProvider{name: "sink:file", isDefer: true}, []string{"log.sink"}})
+
+	// 替换之后旧的实例被关闭，旧的标签被去掉
+	if err := c.Bind(&testProvider{name: "orm"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(closed, ","); got != "orm" {
+		t.Fatalf("closed = %s, replaced instance should be shutdown", got)
+	}
+	if err := c.Bind(&testProvider{name: "sink:file", isDefer: true}); err != nil {
+		t.Fatal(err)
+	}
+	if sinks, _ := c.MakeTagged("log.sink"); len(sinks) != 0 {
+		t.Fatalf("MakeTagged() = %v, stale tags should be removed", sinks)
+	}
+
+	// 绑定触发的其他服务实例化失败的时候也返回它们的错误
+	_ = c.Bind(&failProvider{testProvider{name: "cache", depends: []string{"redis"}}})
+	if err := c.Bind(&testProvider{name: "redis"}); err == nil || !strings.Contains(err.Error(), "cache failed") {
+		t.Fatalf("bind redis should return error of cache, got %v", err)
+	}
+	if _, err := c.Make("cache"); err == nil {
+		t.Fatal("make cache should return its error")
+	}
+
+	// 重复绑定或者替换为延迟加载的服务的时候，等待中的旧服务不会被实例化
+	var booted []string
+	_ = c.Bind(&testProvider{name: "queue", depends: []string{"broker"}, booted: &booted})
+	_ = c.Bind(&testProvider{name: "queue", depends: []string{"broker"}, booted: &booted})
+	_ = c.Bind(&testProvider{name: "mailer", depends: []string{"broker"}, booted: &booted})
+	_ = c.Bind(&testProvider{name: "mailer", depends: []string{"broker"}, isDefer: true, booted: &booted})
+	_ = c.Bind(&testProvider{name: "broker"})
+	if got := strings.Join(booted, ","); got != "queue" {
+		t.Fatalf("booted = %s, want queue once", got)
+	}
+}
+
+// scopedProvider 作用域内的服务提供者
+type scopedProvider struct {
+	testProvider
+}
+
+func (p *scopedProvider) IsScoped() bool {
+	return true
+}
+
+// scopedShutdownProvider 实现了 Shutdowner 接口的作用域内的服务提供者
+type scopedShutdownProvider struct {
+	shutdownProvider
+}
+
+func (p *scopedShutdownProvider) IsScoped() bool {
+	return true
+}
+
+func TestHadeScope(t *testing.T) {
+	var booted, closed []string
+	c := NewHadeContainer()
+	_ = c.Bind(&testProvider{name: "config", booted: &booted})
+	_ = c.Bind(&scopedProvider{testProvider{name: "user", depends: []string{"config"}, booted: &booted}})
+	_ = c.Bind(&scopedShutdownProvider{shutdownProvider{testProvider: testProvider{name: "tx"}, closed: &closed}})
+
+	if _, err := c.Make("user"); err == nil {
+		t.Fatal("scoped service should not be made from root container")
+	}
+
+	scope1, scope2 := c.NewScope(), c.NewScope()
+	scope1.MustMake("user")
+	scope1.MustMake("user")
+	scope2.MustMake("user")
+	if got := strings.Join(booted, ","); got != "config,user,user" {
+		t.Fatalf("boot = %s, want config,user,user", got)
+	}
+	if scope1.MustMake("config") != "config" {
+		t.Fatal("scope should fall back to parent container")
+	}
+
+	scope1.MustMake("tx")
+	if err := scope1.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(closed, ","); got != "tx" {
+		t.Fatalf("closed = %s, provider of scoped service should be shutdown", got)
+	}
+	scope1.MustMake("user")
+	if len(booted) != 4 {
+		t.Fatalf("scoped service should be rebuilt after scope shutdown, boot = %v", booted)
+	}
+}
+
+// hookProvider 通过函数自定义 Boot 和实例化过程的服务提供者
+type hookProvider struct {
+	testProvider
+	boot  func(c Container) error
+	build func() (interface{}, error)
+}
+
+func (p *hookProvider) Boot(c Container) error {
+	if p.boot != nil {
+		return p.boot(c)
+	}
+	return nil
+}
+
+func (p *hookProvider) Register(c Container) NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		return p.build()
+	}
+}
+
+func TestHadeContainer_ConcurrentMakeSingleInstance(t *testing.T) {
+	var builds int32
+	c := NewHadeContainer()
+	_ = c.Bind(&hookProvider{
+		testProvider: testProvider{name: "orm", isDefer: true},
+		build: func() (interface{}, error) {
+			atomic.AddInt32(&builds, 1)
+			time.Sleep(10 * time.Millisecond)
+			return &struct{ n int }{}, nil
+		},
+	})
+
+	var wg sync.WaitGroup
+	instances := make([]interface{}, 50)
+	for i := range instances {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			instances[i] = c.MustMake("orm")
+		}(i)
+	}
+	wg.Wait()
+
+	if builds != 1 {
+		t.Fatalf("orm built %d times, want 1", builds)
+	}
+	for _, ins := range instances {
+		if ins != instances[0] {
+			t.Fatal("concurrent Make returned different instances")
+		}
+	}
+}
+
+// scopedHookProvider 作用域内的 hookProvider
+type scopedHookProvider struct {
+	hookProvider
+}
+
+func (p *scopedHookProvider) IsScoped() bool {
+	return true
+}
+
+func TestHadeScope_ConcurrentMakeSingleInstance(t *testing.T) {
+	var builds int32
+	c := NewHadeContainer()
+	_ = c.Bind(&scopedHookProvider{hookProvider{
+		testProvider: testProvider{name: "user"},
+		build: func() (interface{}, error) {
+			atomic.AddInt32(&builds, 1)
+			time.Sleep(10 * time.Millisecond)
+			return &struct{ n int }{}, nil
+		},
+	}})
+
+	scope := c.NewScope()
+	var wg sync.WaitGroup
+	instances := make([]interface{}, 50)
+	for i := range instances {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			instances[i] = scope.MustMake("user")
+		}(i)
+	}
+	wg.Wait()
+
+	if builds != 1 {
+		t.Fatalf("user built %d times in one scope, want 1", builds)
+	}
+	for _, ins := range instances {
+		if ins != instances[0] {
+			t.Fatal("concurrent Make in one scope returned different instances")
+		}
+	}
+}
+
+func TestHadeContainer_ConcurrentMakeBind(t *testing.T) {
+	c := NewHadeContainer()
+	_ = c.Bind(&testProvider{name: "config", isDefer: true})
+	// log 在 Boot 中 Make 了 config，验证嵌套实例化时不会和 Bind 互相死锁
+	_ = c.Bind(&hookProvider{
+		testProvider: testProvider{name: "log", isDefer: true},
+		boot: func(c Container) error {
+			_, err := c.Make("config")
+			return err
+		},
+		build: func() (interface{}, error) {
+			return "log", nil
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(3)
+			go func() {
+				defer wg.Done()
+				if _, err := c.Make("log"); err != nil {
+					t.Error(err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				if _, err := c.MakeNew("log", nil); err != nil {
+					t.Error(err)
+				}
+			}()
+			go func(i int) {
+				defer wg.Done()
+				key := fmt.Sprintf("svc%d", i)
+				_ = c.Bind(&testProvider{name: key, depends: []string{"config"}})
+				c.IsBind(key)
+			}(i)
+		}
+		wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("concurrent Make and Bind deadlocked")
+	}
+}
+
+func TestHadeContainer_CircularMake(t *testing.T) {
+	c := NewHadeContainer()
+	_ = c.Bind(&hookProvider{
+		testProvider: testProvider{name: "a", isDefer: true},
+		boot: func(c Container) error {
+			_, err := c.Make("a")
+			return err
+		},
+		build: func() (interface{}, error) {
+			return "a", nil
+		},
+	})
+	_, err := c.Make("a")
+	if err == nil || !strings.Contains(err.Error(), "circular make detected: a -> a") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// taggedProvider 绑定的时候加入标签的服务提供者
+type taggedProvider struct {
+	testProvider
+	tags []string
+}
+
+func (p *taggedProvider) Tags() []string {
+	return p.tags
+}
+
+func TestHadeContainer_TagAndAlias(t *testing.T) {
+	c := NewHadeContainer()
+	_ = c.Bind(&taggedProvider{testProvider{name: "sink:file", isDefer: true}, []string{"log.sink"}})
+	_ = c.Bind(&testProvider{name: "sink:console", isDefer: true})
+	_ = c.Bind(&taggedProvider{testProvider{name: "sink:remote", isDefer: true}, []string{"log.sink"}})
+	// 后加入标签的服务依然按照注册顺序返回
+	c.Tag("log.sink", "sink:console")
+
+	sinks, err := c.MakeTagged("log.sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fmt.Sprint(sinks); got != "[sink:file sink:console sink:remote]" {
+		t.Fatalf("MakeTagged() = %s", got)
+	}
+
+	if err := c.Alias("logger", "sink:file"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.IsBind("logger") || c.MustMake("logger") != "sink:file" {
+		t.Fatal("alias should resolve to sink:file")
+	}
+	if err := c.Alias("sink:file", "logger"); err == nil {
+		t.Fatal("alias conflicts with bound contract should fail")
+	}
+	_ = c.Alias("a", "b")
+	if err := c.Alias("b", "a"); err == nil || !strings.Contains(err.Error(), "alias cycle") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Bind(&testProvider{name: "logger", isDefer: true}); err == nil {
+		t.Fatal("bind an alias name should fail")
+	}
+}
+
+func TestHadeContainer_Describe(t *testing.T) {
+	c := NewHadeContainer()
+	_ = c.Bind(&testProvider{name: "config"})
+	_ = c.Bind(&testProvider{name: "orm", isDefer: true, depends: []string{"config"}})
+	_ = c.Bind(&testProvider{name: "redis", isDefer: true})
+	c.MustMake("orm")
+
+	descs := c.Describe()
+	if len(descs) != 3 || descs[0].Key != "config" || descs[1].Key != "orm" || descs[2].Key != "redis" {
+		t.Fatalf("Describe() should follow registration order: %+v", descs)
+	}
+	if descs[0].ResolvedBy != "bind" {
+		t.Fatalf("config resolved by %q, want bind", descs[0].ResolvedBy)
+	}
+	if !strings.HasPrefix(descs[1].ResolvedBy, "framework/container_test.go:") {
+		t.Fatalf("orm resolved by %q, want the test file", descs[1].ResolvedBy)
+	}
+	if descs[2].Instantiated || !descs[2].IsDefer {
+		t.Fatalf("redis should be deferred and not instantiated: %+v", descs[2])
+	}
+}
+
+// closingInstance 在关闭的时候记录名称的服务实例
+type closingInstance struct {
+	name   string
+	closed *[]string
+}
+
+func (ins *closingInstance) Shutdown(ctx context.Context) error {
+	*ins.closed = append(*ins.closed, ins.name)
+	return nil
+}
+
+func TestHadeContainer_RebindWhileMaking(t *testing.T) {
+	var closed []string
+	started, release := make(chan struct{}), make(chan struct{})
+	c := NewHadeContainer()
+	_ = c.Bind(&hookProvider{
+		testProvider: testProvider{name: "db", isDefer: true},
+		build: func() (interface{}, error) {
+			close(started)
+			<-release
+			return &closingInstance{name: "old", closed: &closed}, nil
+		},
+	})
+	done := make(chan struct{})
+	go func() {
+		_, _ = c.Make("db")
+		close(done)
+	}()
+
+	// 实例化过程中替换服务提供者，实例化完成之后丢弃的实例需要被关闭
+	<-started
+	_ = c.Bind(&testProvider{name: "db", isDefer: true})
+	close(release)
+	<-done
+	if got := strings.Join(closed, ","); got != "old" {
+		t.Fatalf("closed = %s, discarded instance should be shutdown", got)
+	}
+	if c.MustMake("db") != "db" {
+		t.Fatal("make should use the new provider")
+	}
+}
+
+func TestHadeContainer_CircularMakeAcrossGoroutines(t *testing.T) {
+	c := NewHadeContainer()
+	// a 在实例化过程中启动新的 goroutine 获取 b 并等待，b 又依赖 a，会互相等待
+	_ = c.Bind(&hookProvider{
+		testProvider: testProvider{name: "a", isDefer: true},
+		boot: func(c Container) error {
+			errs := make(chan error)
+			go func() {
+				_, err := c.Make("b")
+				errs <- err
+			}()
+			return <-errs
+		},
+		build: func() (interface{}, error) {
+			return "a", nil
+		},
+	})
+	_ = c.Bind(&testProvider{name: "b", isDefer: true, depends: []string{"a"}})
+	_, err := c.Make("a")
+	if err == nil || !strings.Contains(err.Error(), "circular make detected") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
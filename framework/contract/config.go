@@ -4,6 +4,12 @@ import "time"
 
 const ConfigKey = "hade:config"
 
+// Config 配置服务，同一个配置项的优先级从高到低为：
+//  1. Set 设置的配置，包括命令行的 --set key=value 参数
+//  2. HADE_ 开头的环境变量，使用 __ 分隔配置路径，比如 HADE_APP__ADDRESS 覆盖 app.address
+//  3. 远程配置中心中的配置文件，设置了 HADE_CONFIG_REMOTE 的时候读取
+//  4. 当前环境目录中的配置文件，比如 config/production/app.yaml
+//  5. base 目录中的配置文件，比如 config/base/app.yaml
 type Config interface {
 	IsExist(key string) bool
 
@@ -30,4 +36,28 @@ type Config interface {
 	GetStringMapStringSlice(key string) map[string][]string
 
 	Load(key string, val interface{}) error
+
+	// Set 在运行时覆盖 key 对应的配置，订阅了 key 的 Watch 会收到通知
+	Set(key string, val interface{}) error
+
+	// Watch 订阅 key 下配置的变化，配置文件热更新或者远程配置更新之后 key 对应的值有变化的时候调用 fn
+	// 返回的函数用于取消订阅
+	Watch(key string, fn ConfigWatcher) (cancel func())
+}
+
+// ConfigWatcher 配置变化的回调，oldVal 和 newVal 分别为变化前后 key 对应的值，不存在的时候为 nil
+type ConfigWatcher func(oldVal, newVal interface{})
+
+// ConfigSourcer 可以查询配置项来源文件的配置服务
+type ConfigSourcer interface {
+	// Sources 返回 key 下每个配置项（叶子节点）的来源文件，key 为空的时候返回所有配置项的来源
+	Sources(key string) map[string]string
+	// Locate 返回 key 所在的文件和行号，key 不存在的时候返回最近的上级配置项的位置，行号未知的时候为 0
+	Locate(key string) (file string, line int)
+}
+
+// ConfigSecretChecker 可以判断配置项是否为加密配置的配置服务
+type ConfigSecretChecker interface {
+	// IsSecret 判断 key 对应的配置项是否是从 enc(...) 解密得到的
+	IsSecret(key string) bool
 }
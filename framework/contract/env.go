@@ -1,5 +1,7 @@
 package contract
 
+import "time"
+
 const (
 	// 开发环境
 	EnvDevelopment = "development"
@@ -25,4 +27,48 @@ type Env interface {
 
 	// 获取所有环境变量的值，.env和运行环境变量融合之后的结果
 	All() map[string]string
+
+	// GetInt 获取整数类型的环境变量，没有设置或者格式错误的时候返回 def
+	GetInt(key string, def int) int
+
+	// GetBool 获取布尔类型的环境变量，支持 1、t、true、0、f、false 等，没有设置或者格式错误的时候返回 def
+	GetBool(key string, def bool) bool
+
+	// GetDuration 获取时间间隔类型的环境变量，比如 5s，没有设置或者格式错误的时候返回 def
+	GetDuration(key string, def time.Duration) time.Duration
+
+	// GetStringSlice 获取逗号分隔的环境变量，没有设置的时候返回 def
+	GetStringSlice(key string, def []string) []string
+}
+
+// 环境变量的类型
+const (
+	EnvTypeString   = "string"
+	EnvTypeInt      = "int"
+	EnvTypeBool     = "bool"
+	EnvTypeDuration = "duration"
+	EnvTypeList     = "list" // 逗号分隔的列表
+)
+
+// EnvVar 应用声明使用的环境变量，env 服务启动的时候检查
+type EnvVar struct {
+	Name        string
+	Description string
+	Required    bool   // 是否必须设置
+	Type        string // 值的类型，用于检查格式，默认为 EnvTypeString
+	Secret      bool   // 是否为密码等敏感信息，hade env list 的时候隐藏
+}
+
+// EnvIssue 声明的环境变量没有设置或者格式错误
+type EnvIssue struct {
+	Var     EnvVar
+	Problem string
+}
+
+// EnvChecker 可以检查应用声明的环境变量的 env 服务
+type EnvChecker interface {
+	// Vars 返回应用声明的环境变量
+	Vars() []EnvVar
+	// Check 返回声明的环境变量中没有设置或者格式错误的变量
+	Check() []EnvIssue
 }
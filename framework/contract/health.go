@@ -0,0 +1,48 @@
+package contract
+
+import "context"
+
+const HealthKey = "hade:health"
+
+// HealthCheckerTag 服务提供者带有这个标签的时候，它的实例（HealthChecker 或者 []HealthChecker）会作为就绪检查项
+const HealthCheckerTag = "health.checker"
+
+const (
+	HealthStatusUp   = "up"
+	HealthStatusDown = "down"
+)
+
+// HealthChecker 健康检查项
+type HealthChecker interface {
+	// Name 检查项的名称，比如 db:default
+	Name() string
+	// Check 进行检查，返回 nil 表示检查通过
+	Check(ctx context.Context) error
+}
+
+// HealthCheckResult 单个检查项的结果
+type HealthCheckResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"` // 检查的耗时，比如 1.2ms
+}
+
+// HealthReport 一次检查的结果，所有检查项都通过的时候 Status 为 up
+type HealthReport struct {
+	Status string              `json:"status"`
+	Checks []HealthCheckResult `json:"checks"`
+}
+
+// Health 健康检查服务
+type Health interface {
+	// Register 注册就绪检查项，比如数据库和 redis 的连通性
+	Register(checkers ...HealthChecker)
+	// RegisterLiveness 注册存活检查项，存活检查项同时也是就绪检查项
+	RegisterLiveness(checkers ...HealthChecker)
+
+	// Liveness 进行存活检查，进程能够正常响应的时候就是存活的
+	Liveness(ctx context.Context) *HealthReport
+	// Readiness 进行就绪检查，依赖的外部服务都可用的时候才是就绪的
+	Readiness(ctx context.Context) *HealthReport
+}
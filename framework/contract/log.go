@@ -48,6 +48,28 @@ type Log interface {
 	// Trace 表示最详细的信息，一般信息量比较大，可能包含调用堆栈等信息
 	Trace(ctx context.Context, msg string, fields map[string]interface{})
 
+	// Panicf 等方法使用 fmt.Sprintf 格式化日志信息，没有额外的字段
+	Panicf(ctx context.Context, format string, args ...interface{})
+	Fatalf(ctx context.Context, format string, args ...interface{})
+	Errorf(ctx context.Context, format string, args ...interface{})
+	Warnf(ctx context.Context, format string, args ...interface{})
+	Infof(ctx context.Context, format string, args ...interface{})
+	Debugf(ctx context.Context, format string, args ...interface{})
+	Tracef(ctx context.Context, format string, args ...interface{})
+
+	// Panicw 等方法使用 k1, v1, k2, v2 格式的参数作为日志的字段，比如 Infow(ctx, "login", "user", 1)
+	Panicw(ctx context.Context, msg string, keysAndValues ...interface{})
+	Fatalw(ctx context.Context, msg string, keysAndValues ...interface{})
+	Errorw(ctx context.Context, msg string, keysAndValues ...interface{})
+	Warnw(ctx context.Context, msg string, keysAndValues ...interface{})
+	Infow(ctx context.Context, msg string, keysAndValues ...interface{})
+	Debugw(ctx context.Context, msg string, keysAndValues ...interface{})
+	Tracew(ctx context.Context, msg string, keysAndValues ...interface{})
+
+	// With 返回绑定了 fields 的子日志，子日志输出的每一条日志都带有这些字段，不影响当前日志
+	// 子日志和当前日志共用日志级别、输出格式和输出管道等设置
+	With(fields map[string]interface{}) Log
+
 	// SetLevel 设置日志级别
 	SetLevel(level LogLevel)
 	// SetCtxFielder 从context中获取上下文字段field
@@ -57,3 +79,9 @@ type Log interface {
 	// SetOutput 设置输出管道
 	SetOutput(out io.Writer)
 }
+
+// LogDropCounter 可以查询丢弃的日志数量的日志服务，异步写入的缓冲区满了之后可能会丢弃日志
+type LogDropCounter interface {
+	// Dropped 返回丢弃的日志行数
+	Dropped() uint64
+}
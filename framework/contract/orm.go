@@ -16,28 +16,28 @@ type DBOption func(container framework.Container, config *DBConfig) error
 
 type DBConfig struct {
 	// 以下配置关于dsn
-	ParseTime            bool   `yaml:"parse_time"`    // 是否解析时间
-	ReadTimeout          string `yaml:"read_timeout"`  // 读超时时间
-	WriteTimeout         string `yaml:"write_timeout"` // 写超时时间
-	Loc                  string `yaml:"loc"`           // 时区
-	Port                 int    `yaml:"port"`          // 端口
-	Charset              string `yaml:"charset"`       // 字符集
-	Protocol             string `yaml:"protocol"`      // 传输协议
-	Dsn                  string `yaml:"dsn"`           // 直接传递dsn，如果传递了，其他关于dsn的配置均无效
-	Database             string `yaml:"database"`      // 数据库
-	Collation            string `yaml:"collation"`     // 字符序
-	Timeout              string `yaml:"timeout"`       // 连接超时时间
-	Username             string `yaml:"username"`      // 用户名
-	Password             string `yaml:"password"`      // 密码
-	Driver               string `yaml:"driver"`        // 驱动
-	Host                 string `yaml:"host"`          // 数据库地址
+	ParseTime            bool   `yaml:"parse_time"`                                                                   // 是否解析时间
+	ReadTimeout          string `yaml:"read_timeout" validate:"omitempty,duration"`                                   // 读超时时间
+	WriteTimeout         string `yaml:"write_timeout" validate:"omitempty,duration"`                                  // 写超时时间
+	Loc                  string `yaml:"loc" default:"Local"`                                                          // 时区
+	Port                 int    `yaml:"port" validate:"omitempty,min=1,max=65535"`                                    // 端口
+	Charset              string `yaml:"charset" default:"utf8mb4"`                                                    // 字符集
+	Protocol             string `yaml:"protocol" default:"tcp"`                                                       // 传输协议
+	Dsn                  string `yaml:"dsn"`                                                                          // 直接传递dsn，如果传递了，其他关于dsn的配置均无效
+	Database             string `yaml:"database"`                                                                     // 数据库
+	Collation            string `yaml:"collation"`                                                                    // 字符序
+	Timeout              string `yaml:"timeout" validate:"omitempty,duration"`                                        // 连接超时时间
+	Username             string `yaml:"username"`                                                                     // 用户名
+	Password             string `yaml:"password"`                                                                     // 密码
+	Driver               string `yaml:"driver" validate:"omitempty,oneof=mysql postgres sqlite sqlserver clickhouse"` // 驱动
+	Host                 string `yaml:"host"`                                                                         // 数据库地址
 	AllowNativePasswords bool   `yaml:"allow_native_passwords"`
 
 	// 以下配置关于连接池
-	ConnMaxIdle     int    `yaml:"conn_max_idle"`     // 最大空闲连接数
-	ConnMaxOpen     int    `yaml:"conn_max_open"`     // 最大连接数
-	ConnMaxLifetime string `yaml:"conn_max_lifetime"` // 连接最大生命周期
-	ConnMaxIdletime string `yaml:"conn_max_idletime"` // 空闲最大生命周期
+	ConnMaxIdle     int    `yaml:"conn_max_idle" validate:"min=0"`                  // 最大空闲连接数
+	ConnMaxOpen     int    `yaml:"conn_max_open" validate:"min=0"`                  // 最大连接数
+	ConnMaxLifetime string `yaml:"conn_max_lifetime" validate:"omitempty,duration"` // 连接最大生命周期
+	ConnMaxIdletime string `yaml:"conn_max_idletime" validate:"omitempty,duration"` // 空闲最大生命周期
 
 	// 以下配置关于gorm
 	*gorm.Config // 集成gorm的配置
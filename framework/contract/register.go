@@ -0,0 +1,17 @@
+package contract
+
+import "github.com/yefangyong/go-frame/framework"
+
+// 注册框架内置服务的接口类型，Container.Invoke 和 Container.Fill 根据接口类型获取服务
+func init() {
+	framework.RegisterContract(AppKey, (*App)(nil))
+	framework.RegisterContract(CacheKey, (*CacheService)(nil))
+	framework.RegisterContract(ConfigKey, (*Config)(nil))
+	framework.RegisterContract(DistributedKey, (*Distributed)(nil))
+	framework.RegisterContract(EnvKey, (*Env)(nil))
+	framework.RegisterContract(HealthKey, (*Health)(nil))
+	framework.RegisterContract(KernelKey, (*Kernel)(nil))
+	framework.RegisterContract(LogKey, (*Log)(nil))
+	framework.RegisterContract(ORMKEY, (*ORMService)(nil))
+	framework.RegisterContract(RedisKey, (*RedisService)(nil))
+}
@@ -0,0 +1,115 @@
+package framework
+
+import (
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// frameworkPkg 当前包的路径，用于在调用堆栈中过滤容器内部的调用
+var frameworkPkg = reflect.TypeOf(HadeContainer{}).PkgPath()
+
+// instanceStat 记录服务实例化的统计信息
+type instanceStat struct {
+	bootCost     time.Duration // Boot 的耗时
+	registerCost time.Duration // Params、Register 以及实例化函数的耗时
+	resolvedBy   string        // 第一次触发实例化的调用者
+}
+
+// ProviderDescription 描述容器中的一个服务提供者，以及它对应服务的实例化情况
+type ProviderDescription struct {
+	Key          string        // 服务凭证
+	Type         string        // 服务提供者的 Go 类型
+	InstanceType string        // 服务实例的 Go 类型，没有实例化的时候为空
+	IsDefer      bool          // 是否延迟实例化
+	IsScoped     bool          // 是否为作用域内的服务
+	Instantiated bool          // 是否已经在容器中实例化
+	BootCost     time.Duration // Boot 的耗时
+	RegisterCost time.Duration // Params、Register 以及实例化函数的耗时
+	ResolvedBy   string        // 第一次触发实例化的调用者，bind 表示绑定时实例化，depends of xx 表示作为 xx 的依赖实例化
+	Depends      []string      // 依赖的服务凭证
+	Tags         []string      // 所属的标签
+	Aliases      []string      // 别名
+}
+
+// Describe 按照注册顺序返回容器中所有服务提供者的描述信息
+func (hade *HadeContainer) Describe() []ProviderDescription {
+	hade.lock.RLock()
+	defer hade.lock.RUnlock()
+
+	keyTags := map[string][]string{}
+	for tag, keys := range hade.tags {
+		for _, key := range keys {
+			key = hade.resolveAlias(key)
+			keyTags[key] = append(keyTags[key], tag)
+		}
+	}
+	keyAliases := map[string][]string{}
+	for alias := range hade.aliases {
+		key := hade.resolveAlias(alias)
+		keyAliases[key] = append(keyAliases[key], alias)
+	}
+
+	ret := make([]ProviderDescription, 0, len(hade.order))
+	for _, key := range hade.order {
+		sp := hade.providers[key]
+		desc := ProviderDescription{
+			Key:      key,
+			Type:     reflect.TypeOf(sp).String(),
+			IsDefer:  sp.IsDefer(),
+			IsScoped: isScoped(sp),
+			Depends:  providerDepends(sp),
+			Tags:     keyTags[key],
+			Aliases:  keyAliases[key],
+		}
+		if ins, ok := hade.instances[key]; ok {
+			desc.Instantiated = true
+			if ins != nil {
+				desc.InstanceType = reflect.TypeOf(ins).String()
+			}
+		}
+		if stat, ok := hade.stats[key]; ok {
+			desc.BootCost = stat.bootCost
+			desc.RegisterCost = stat.registerCost
+			desc.ResolvedBy = stat.resolvedBy
+		}
+		ret = append(ret, desc)
+	}
+	return ret
+}
+
+// callerLocation 获取容器外部第一个调用者的代码位置，格式为 目录/文件:行号
+func callerLocation() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		// 测试文件虽然和容器在同一个包中，但是属于外部调用者
+		if !isContainerFrame(frame.Function) || strings.HasSuffix(frame.File, "_test.go") {
+			file := filepath.Join(filepath.Base(filepath.Dir(frame.File)), filepath.Base(frame.File))
+			return file + ":" + strconv.Itoa(frame.Line)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// isContainerFrame 判断调用帧是否属于容器，或者 gin.Context、cobra.Command 等对容器方法的封装
+func isContainerFrame(function string) bool {
+	if strings.HasPrefix(function, frameworkPkg+".") {
+		return true
+	}
+	if !strings.HasPrefix(function, frameworkPkg+"/") {
+		return false
+	}
+	switch function[strings.LastIndex(function, ".")+1:] {
+	case "Make", "MustMake", "MakeNew", "MakeTagged", "Invoke", "Fill":
+		return true
+	}
+	return false
+}
@@ -88,6 +88,7 @@ type Context struct {
 /************************************/
 
 func (ctx *Context) reset() {
+	ctx.container = ctx.engine.container
 	ctx.Writer = &ctx.writermem
 	ctx.Params = ctx.Params[0:0]
 	ctx.handlers = nil
@@ -106,6 +107,7 @@ func (ctx *Context) reset() {
 // This has to be used when the context has to be passed to a goroutine.
 func (ctx *Context) Copy() *Context {
 	cp := Context{
+		container: ctx.engine.container,
 		writermem: ctx.writermem,
 		Request:   ctx.Request,
 		Params:    ctx.Params,
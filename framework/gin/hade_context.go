@@ -5,12 +5,24 @@ package gin
 
 import (
 	"context"
+
+	"github.com/yefangyong/go-frame/framework"
 )
 
 func (ctx *Context) BaseContext() context.Context {
 	return ctx.Request.Context()
 }
 
+// SetContainer 设置当前请求使用的服务容器，比如替换为请求作用域的子容器
+func (ctx *Context) SetContainer(container framework.Container) {
+	ctx.container = container
+}
+
+// GetContainer 获取当前请求使用的服务容器
+func (ctx *Context) GetContainer() framework.Container {
+	return ctx.container
+}
+
 // 实现make的封装
 func (ctx *Context) Make(key string) (interface{}, error) {
 	return ctx.container.Make(key)
@@ -25,3 +37,13 @@ func (ctx *Context) MustMake(key string) interface{} {
 func (ctx *Context) MakeNew(key string, params []interface{}) (interface{}, error) {
 	return ctx.container.MakeNew(key, params)
 }
+
+// 实现invoke的封装
+func (ctx *Context) Invoke(fn interface{}) error {
+	return ctx.container.Invoke(fn)
+}
+
+// 实现fill的封装
+func (ctx *Context) Fill(target interface{}) error {
+	return ctx.container.Fill(target)
+}
@@ -0,0 +1,147 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	// contractTypes 记录接口类型对应的服务凭证，一个接口类型可以对应多个服务凭证
+	contractTypes = map[reflect.Type][]string{}
+	contractLock  sync.RWMutex
+
+	containerType = reflect.TypeOf((*Container)(nil)).Elem()
+	errorType     = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// RegisterContract 注册接口类型对应的服务凭证，iface 为接口的空指针，比如 (*contract.Log)(nil)
+// 注册之后，Invoke 和 Fill 就可以根据接口类型从容器中获取对应的服务
+func RegisterContract(key string, iface interface{}) {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic("framework: RegisterContract expects a pointer to an interface, got " + fmt.Sprint(t))
+	}
+	t = t.Elem()
+
+	contractLock.Lock()
+	defer contractLock.Unlock()
+	for _, k := range contractTypes[t] {
+		if k == key {
+			return
+		}
+	}
+	contractTypes[t] = append(contractTypes[t], key)
+}
+
+// resolveType 根据类型从容器中获取服务，参数类型为 Container 的时候直接返回容器本身
+func resolveType(c Container, t reflect.Type) (reflect.Value, error) {
+	if t == containerType {
+		return reflect.ValueOf(&c).Elem(), nil
+	}
+
+	contractLock.RLock()
+	keys := contractTypes[t]
+	contractLock.RUnlock()
+	if len(keys) == 0 {
+		return reflect.Value{}, errors.New("no contract registered for type " + t.String())
+	}
+
+	// 只有绑定了服务提供者的服务凭证才是候选
+	var bound []string
+	for _, key := range keys {
+		if c.IsBind(key) {
+			bound = append(bound, key)
+		}
+	}
+	switch len(bound) {
+	case 0:
+		return reflect.Value{}, errors.New("contract " + strings.Join(keys, ",") + " for type " + t.String() + " have not register")
+	case 1:
+		return resolveKey(c, bound[0], t)
+	default:
+		return reflect.Value{}, errors.New("ambiguous binding for type " + t.String() + ": " + strings.Join(bound, ","))
+	}
+}
+
+// resolveKey 根据服务凭证从容器中获取服务，并检查服务是否可以赋值给类型 t
+func resolveKey(c Container, key string, t reflect.Type) (reflect.Value, error) {
+	ins, err := c.Make(key)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if ins == nil {
+		return reflect.Zero(t), nil
+	}
+	v := reflect.ValueOf(ins)
+	if !v.Type().AssignableTo(t) {
+		return reflect.Value{}, errors.New("contract " + key + " instance " + v.Type().String() + " is not assignable to " + t.String())
+	}
+	return v, nil
+}
+
+// invoke 根据函数参数的类型从容器中获取服务并调用函数，函数最后一个返回值为 error 的时候会返回这个错误
+func invoke(c Container, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		return errors.New("invoke: fn must be a function, got " + fmt.Sprint(reflect.TypeOf(fn)))
+	}
+	t := v.Type()
+	if t.IsVariadic() {
+		return errors.New("invoke: variadic function " + t.String() + " is not supported")
+	}
+
+	args := make([]reflect.Value, t.NumIn())
+	for i := range args {
+		arg, err := resolveType(c, t.In(i))
+		if err != nil {
+			return fmt.Errorf("invoke %s: parameter %d: %v", t.String(), i, err)
+		}
+		args[i] = arg
+	}
+
+	outs := v.Call(args)
+	if n := len(outs); n > 0 && t.Out(n-1) == errorType && !outs[n-1].IsNil() {
+		return outs[n-1].Interface().(error)
+	}
+	return nil
+}
+
+// fill 为结构体中带有 hade:"inject" 标签的字段注入服务
+// 标签 hade:"inject" 根据字段类型查找服务，hade:"inject:<key>" 直接使用指定的服务凭证
+func fill(c Container, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New("fill: target must be a pointer to struct, got " + fmt.Sprint(reflect.TypeOf(target)))
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("hade")
+		if !ok || (tag != "inject" && !strings.HasPrefix(tag, "inject:")) {
+			continue
+		}
+		name := t.Name() + "." + field.Name
+		if field.PkgPath != "" {
+			return errors.New("fill " + name + ": unexported field can not be injected")
+		}
+
+		var (
+			val reflect.Value
+			err error
+		)
+		if key := strings.TrimPrefix(tag, "inject:"); key != tag {
+			val, err = resolveKey(c, key, field.Type)
+		} else {
+			val, err = resolveType(c, field.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("fill %s: %v", name, err)
+		}
+		v.Field(i).Set(val)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package framework
+
+import (
+	"strings"
+	"testing"
+)
+
+type injectNamer interface {
+	Name() string
+}
+
+type injectNamed string
+
+func (n injectNamed) Name() string {
+	return string(n)
+}
+
+// injectProvider 实例化一个 injectNamed
+type injectProvider struct {
+	testProvider
+}
+
+func (p *injectProvider) Register(c Container) NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		return injectNamed(p.name), nil
+	}
+}
+
+func TestInvokeAndFill(t *testing.T) {
+	RegisterContract("inject:a", (*injectNamer)(nil))
+	c := NewHadeContainer()
+
+	err := c.Invoke(func(n injectNamer) {})
+	if err == nil || !strings.Contains(err.Error(), "have not register") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_ = c.Bind(&injectProvider{testProvider{name: "inject:a", isDefer: true}})
+	var got string
+	err = c.Invoke(func(n injectNamer, container Container) error {
+		got = n.Name()
+		if container != c {
+			t.Fatal("Container parameter should be the container itself")
+		}
+		return nil
+	})
+	if err != nil || got != "inject:a" {
+		t.Fatalf("Invoke() = %v, name %q", err, got)
+	}
+
+	target := &struct {
+		Namer injectNamer `hade:"inject"`
+		Other injectNamer `hade:"inject:inject:a"`
+		Skip  injectNamer
+	}{}
+	if err := c.Fill(target); err != nil {
+		t.Fatal(err)
+	}
+	if target.Namer == nil || target.Other == nil || target.Skip != nil {
+		t.Fatalf("unexpected fill result: %+v", target)
+	}
+
+	RegisterContract("inject:b", (*injectNamer)(nil))
+	_ = c.Bind(&injectProvider{testProvider{name: "inject:b", isDefer: true}})
+	err = c.Invoke(func(n injectNamer) {})
+	if err == nil || !strings.Contains(err.Error(), "ambiguous binding") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package framework
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// instanceCall 代表一次正在进行的实例化
+type instanceCall struct {
+	done    chan struct{} // 实例化完成之后关闭
+	key     string        // 实例化的服务凭证
+	parent  *instanceCall // 在哪一次实例化的过程中触发，容器外部调用的时候为空
+	version int           // 开始实例化时服务凭证的绑定次数
+	ins     interface{}
+	err     error
+
+	// 下面的字段由 callLock 保护，用于发现互相等待
+	child   *instanceCall // 在这次实例化的过程中正在进行的嵌套实例化
+	waitFor *instanceCall // 正在等待的由其他 goroutine 进行的实例化
+}
+
+// callLock 保护所有实例化的 child 和 waitFor，容器和作用域中的实例化可以互相等待，所以使用同一个锁
+var callLock sync.Mutex
+
+// maker 可以在某次实例化的过程中获取服务的容器，比如 HadeContainer 和 HadeScope
+type maker interface {
+	Container
+	// makeFrom 在实例化 call 的过程中获取服务，call 为空的时候和容器外部调用一样
+	makeFrom(call *instanceCall, key string, params []interface{}, forceNew bool) (interface{}, error)
+	// taggedKeys 返回标签下的服务凭证
+	taggedKeys(tag string) []string
+}
+
+// makingContainer 实例化过程中传给服务提供者的容器
+// 通过它获取的服务会记录是在哪一次实例化中触发的，用于发现服务直接或者间接地 Make 了自己
+type makingContainer struct {
+	maker
+	call *instanceCall
+}
+
+// withCall 返回实例化 call 的时候传给服务提供者的容器
+func withCall(c maker, call *instanceCall) Container {
+	if call == nil {
+		return c
+	}
+	return &makingContainer{maker: c, call: call}
+}
+
+func (m *makingContainer) Make(key string) (interface{}, error) {
+	return m.makeFrom(m.call, key, nil, false)
+}
+
+func (m *makingContainer) MustMake(key string) interface{} {
+	ins, err := m.makeFrom(m.call, key, nil, false)
+	if err != nil {
+		panic(err)
+	}
+	return ins
+}
+
+func (m *makingContainer) MakeNew(key string, params []interface{}) (interface{}, error) {
+	return m.makeFrom(m.call, key, params, true)
+}
+
+func (m *makingContainer) MakeTagged(tag string) ([]interface{}, error) {
+	return makeTagged(m, m.taggedKeys(tag))
+}
+
+func (m *makingContainer) Invoke(fn interface{}) error {
+	return invoke(m, fn)
+}
+
+func (m *makingContainer) Fill(target interface{}) error {
+	return fill(m, target)
+}
+
+// activeCall 返回还没有完成的 call，服务保存了实例化时的容器并且在实例化完成之后使用的时候，不再属于那次实例化
+func activeCall(call *instanceCall) *instanceCall {
+	if call == nil {
+		return nil
+	}
+	select {
+	case <-call.done:
+		return nil
+	default:
+		return call
+	}
+}
+
+// beginCall 记录 call 是在 parent 的过程中进行的嵌套实例化
+func beginCall(parent, call *instanceCall) {
+	if parent == nil {
+		return
+	}
+	callLock.Lock()
+	parent.child = call
+	callLock.Unlock()
+}
+
+// endCall 嵌套的实例化完成之后调用
+func endCall(parent *instanceCall) {
+	if parent == nil {
+		return
+	}
+	callLock.Lock()
+	parent.child = nil
+	callLock.Unlock()
+}
+
+// waitCall 在实例化 parent 的过程中等待其他 goroutine 进行的实例化 target 完成
+// 等待会导致互相等待的时候返回错误，比如服务在实例化的过程中直接或者间接地 Make 了自己
+func waitCall(parent, target *instanceCall) error {
+	if parent == nil {
+		<-target.done
+		return nil
+	}
+	callLock.Lock()
+	if err := checkWait(parent, target); err != nil {
+		callLock.Unlock()
+		return err
+	}
+	parent.waitFor = target
+	callLock.Unlock()
+
+	<-target.done
+
+	callLock.Lock()
+	parent.waitFor = nil
+	callLock.Unlock()
+	return nil
+}
+
+// checkWait 沿着 target 所在的实例化链以及它们等待的实例化查找，回到 parent 所在的实例化链的时候说明会互相等待
+// 同一个实例化链中的实例化在同一个 goroutine 中进行，调用方需要持有 callLock
+func checkWait(parent, target *instanceCall) error {
+	self := rootCall(parent)
+	chain := []string{target.key}
+	for call := target; ; {
+		if rootCall(call) == self {
+			return errors.New("circular make detected: " + strings.Join(append(chain, target.key), " -> "))
+		}
+		for call.child != nil {
+			call = call.child
+		}
+		if call.waitFor == nil {
+			return nil
+		}
+		call = call.waitFor
+		chain = append(chain, call.key)
+	}
+}
+
+// rootCall 返回实例化链最外层的实例化
+func rootCall(call *instanceCall) *instanceCall {
+	for call.parent != nil {
+		call = call.parent
+	}
+	return call
+}
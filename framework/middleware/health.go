@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/gin"
+)
+
+// HealthLiveness 存活检查的处理函数，一般注册在 /healthz
+func HealthLiveness() gin.HandlerFunc {
+	return healthHandler(func(health contract.Health, c *gin.Context) *contract.HealthReport {
+		return health.Liveness(c.Request.Context())
+	})
+}
+
+// HealthReadiness 就绪检查的处理函数，一般注册在 /readyz，有检查项失败的时候返回 503
+func HealthReadiness() gin.HandlerFunc {
+	return healthHandler(func(health contract.Health, c *gin.Context) *contract.HealthReport {
+		return health.Readiness(c.Request.Context())
+	})
+}
+
+func healthHandler(check func(health contract.Health, c *gin.Context) *contract.HealthReport) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ins, err := c.Make(contract.HealthKey)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, &contract.HealthReport{
+				Status: contract.HealthStatusDown,
+				Checks: []contract.HealthCheckResult{{Name: contract.HealthKey, Status: contract.HealthStatusDown, Error: err.Error()}},
+			})
+			return
+		}
+		report := check(ins.(contract.Health), c)
+		code := http.StatusOK
+		if report.Status != contract.HealthStatusUp {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, report)
+	}
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"context"
+	"log"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/gin"
+)
+
+// RequestScope 为每个请求创建一个子作用域，请求内获取的作用域服务只实例化一次，请求结束之后释放
+func RequestScope() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		container, ok := c.GetContainer().(*framework.HadeContainer)
+		if !ok {
+			c.Next()
+			return
+		}
+		scope := container.NewScope()
+		c.SetContainer(scope)
+		defer func() {
+			// 请求的 context 可能已经被取消，释放资源使用独立的 context
+			if err := scope.Shutdown(context.Background()); err != nil {
+				log.Println(err)
+			}
+			c.SetContainer(container)
+		}()
+		c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+// Package module 将一个功能的服务提供者、路由、命令和定时任务组织成一个模块
+// 模块在 main 中注册一次，服务提供者在注册的时候绑定，路由由 HTTP 内核注册，命令和定时任务由命令行内核注册
+//
+// 模块的实现依赖 gin 和 cobra，而这两个包又依赖 framework，所以模块放在单独的包中
+package module
+
+import (
+	"time"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/cobra"
+	"github.com/yefangyong/go-frame/framework/gin"
+)
+
+// Module 模块，一个模块至少有名称，其他部分通过可选的接口提供
+type Module interface {
+	// Name 模块名称，在 app.yaml 的 modules 中使用这个名称开启或者关闭模块
+	Name() string
+}
+
+// ModuleProviders 模块需要绑定的服务提供者
+type ModuleProviders interface {
+	Providers() []framework.ServiceProvider
+}
+
+// ModuleRoutes 模块需要注册的路由
+type ModuleRoutes interface {
+	Routes(r *gin.Engine) error
+}
+
+// ModuleCommands 模块需要注册的命令
+type ModuleCommands interface {
+	Commands() []*cobra.Command
+}
+
+// ModuleCron 模块需要注册的定时任务
+type ModuleCron interface {
+	CronJobs() []CronJob
+}
+
+// CronJob 定时任务，设置了 ServiceName 的时候为分布式定时任务
+type CronJob struct {
+	// Spec 定时任务的时间描述，比如 */5 * * * * *
+	Spec string
+	// Command 定时执行的命令
+	Command *cobra.Command
+	// ServiceName 分布式定时任务的服务名称，同一时间只有一个节点执行
+	ServiceName string
+	// HoldTime 分布式定时任务抢占到之后持有锁的时间
+	HoldTime time.Duration
+}
@@ -0,0 +1,176 @@
+package module
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/cobra"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/gin"
+)
+
+// Key 模块注册表在服务容器中的凭证
+const Key = "hade:module"
+
+// Registry 记录注册到服务容器中的模块
+type Registry struct {
+	lock     sync.RWMutex
+	modules  []Module
+	disabled []string
+}
+
+// Modules 返回开启的模块，按照注册顺序排列
+func (r *Registry) Modules() []Module {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return append([]Module{}, r.modules...)
+}
+
+// Disabled 返回在配置中关闭的模块名称
+func (r *Registry) Disabled() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return append([]string{}, r.disabled...)
+}
+
+// registryProvider 将注册表绑定到服务容器中
+type registryProvider struct {
+	registry *Registry
+}
+
+func (p *registryProvider) Register(container framework.Container) framework.NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		return p.registry, nil
+	}
+}
+
+func (p *registryProvider) Boot(container framework.Container) error {
+	return nil
+}
+
+func (p *registryProvider) IsDefer() bool {
+	return true
+}
+
+func (p *registryProvider) Params(container framework.Container) []interface{} {
+	return nil
+}
+
+func (p *registryProvider) Name() string {
+	return Key
+}
+
+// Register 注册模块并绑定开启的模块的服务提供者
+// 配置 app.modules.<模块名称> 为 false 的时候模块关闭，没有配置的模块默认开启
+func Register(container framework.Container, modules ...Module) error {
+	registry, err := registryOf(container)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range modules {
+		name := m.Name()
+		if isRegistered(registry, name) {
+			return errors.New("module " + name + " is already registered")
+		}
+		if !isEnabled(container, name) {
+			registry.lock.Lock()
+			registry.disabled = append(registry.disabled, name)
+			registry.lock.Unlock()
+			continue
+		}
+		if mp, ok := m.(ModuleProviders); ok {
+			for _, provider := range mp.Providers() {
+				if err := container.Bind(provider); err != nil {
+					return errors.New("module " + name + ": " + err.Error())
+				}
+			}
+		}
+		registry.lock.Lock()
+		registry.modules = append(registry.modules, m)
+		registry.lock.Unlock()
+	}
+	return nil
+}
+
+// Modules 返回服务容器中开启的模块
+func Modules(container framework.Container) []Module {
+	if !container.IsBind(Key) {
+		return nil
+	}
+	return container.MustMake(Key).(*Registry).Modules()
+}
+
+// RegisterRoutes 注册所有开启的模块的路由，由 HTTP 内核调用
+func RegisterRoutes(r *gin.Engine) error {
+	for _, m := range Modules(r.GetContainer()) {
+		if mr, ok := m.(ModuleRoutes); ok {
+			if err := mr.Routes(r); err != nil {
+				return errors.New("module " + m.Name() + " routes: " + err.Error())
+			}
+		}
+	}
+	return nil
+}
+
+// RegisterCommands 注册所有开启的模块的命令和定时任务，由命令行内核调用
+func RegisterCommands(root *cobra.Command) {
+	for _, m := range Modules(root.GetContainer()) {
+		if mc, ok := m.(ModuleCommands); ok {
+			for _, cmd := range mc.Commands() {
+				root.AddCommand(cmd)
+			}
+		}
+		if mc, ok := m.(ModuleCron); ok {
+			for _, job := range mc.CronJobs() {
+				if job.ServiceName != "" {
+					root.AddDistributedCronCommand(job.ServiceName, job.Spec, job.Command, job.HoldTime)
+				} else {
+					root.AddCronCommand(job.Spec, job.Command)
+				}
+			}
+		}
+	}
+}
+
+// registryOf 获取服务容器中的模块注册表，没有的时候创建一个
+func registryOf(container framework.Container) (*Registry, error) {
+	if !container.IsBind(Key) {
+		if err := container.Bind(&registryProvider{registry: &Registry{}}); err != nil {
+			return nil, err
+		}
+	}
+	ins, err := container.Make(Key)
+	if err != nil {
+		return nil, err
+	}
+	return ins.(*Registry), nil
+}
+
+// isRegistered 模块名称是否已经注册过，关闭的模块也算注册过
+func isRegistered(registry *Registry, name string) bool {
+	registry.lock.RLock()
+	defer registry.lock.RUnlock()
+	for _, m := range registry.modules {
+		if m.Name() == name {
+			return true
+		}
+	}
+	for _, disabled := range registry.disabled {
+		if disabled == name {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnabled 模块是否开启，没有绑定配置服务的时候所有模块都开启
+func isEnabled(container framework.Container, name string) bool {
+	if !container.IsBind(contract.ConfigKey) {
+		return true
+	}
+	configService := container.MustMake(contract.ConfigKey).(contract.Config)
+	key := "app.modules." + name
+	return !configService.IsExist(key) || configService.GetBool(key)
+}
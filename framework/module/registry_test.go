@@ -0,0 +1,83 @@
+package module
+
+import (
+	"testing"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/cobra"
+	"github.com/yefangyong/go-frame/framework/gin"
+	hadetest "github.com/yefangyong/go-frame/framework/testing"
+)
+
+// testModule 提供一个服务、一个路由、一个命令和一个定时任务的模块
+type testModule struct {
+	name string
+}
+
+func (m *testModule) Name() string {
+	return m.name
+}
+
+func (m *testModule) Providers() []framework.ServiceProvider {
+	return []framework.ServiceProvider{&valueProvider{key: m.name + ":service"}}
+}
+
+func (m *testModule) Routes(r *gin.Engine) error {
+	r.GET("/"+m.name, func(c *gin.Context) {
+		c.JSON(200, c.MustMake(m.name+":service"))
+	})
+	return nil
+}
+
+func (m *testModule) Commands() []*cobra.Command {
+	return []*cobra.Command{{Use: m.name}}
+}
+
+func (m *testModule) CronJobs() []CronJob {
+	return []CronJob{{Spec: "* * * * * *", Command: &cobra.Command{Use: m.name + "-cron"}}}
+}
+
+type valueProvider struct {
+	key string
+}
+
+func (p *valueProvider) Register(c framework.Container) framework.NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		return p.key, nil
+	}
+}
+func (p *valueProvider) Boot(c framework.Container) error           { return nil }
+func (p *valueProvider) IsDefer() bool                              { return true }
+func (p *valueProvider) Params(c framework.Container) []interface{} { return nil }
+func (p *valueProvider) Name() string                               { return p.key }
+
+func TestRegister(t *testing.T) {
+	tc := hadetest.NewTestContainer(t, hadetest.WithConfig(map[string]interface{}{
+		"app": map[string]interface{}{
+			"modules": map[string]interface{}{"off": false},
+		},
+	}))
+	if err := Register(tc, &testModule{name: "on"}, &testModule{name: "off"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(tc, &testModule{name: "on"}); err == nil {
+		t.Fatal("register a module twice should fail")
+	}
+	if !tc.IsBind("on:service") || tc.IsBind("off:service") {
+		t.Fatal("only providers of enabled modules should be bound")
+	}
+
+	r := tc.NewEngine(RegisterRoutes)
+	r.GET("/on").Do().AssertStatus(200).AssertJSON("on:service")
+	r.GET("/off").Do().AssertStatus(404)
+
+	root := &cobra.Command{Use: "hade"}
+	root.SetContainer(tc)
+	RegisterCommands(root)
+	if len(root.Commands()) != 1 || root.Commands()[0].Use != "on" {
+		t.Fatalf("unexpected commands: %v", root.Commands())
+	}
+	if len(root.CronSpec) != 1 || root.CronSpec[0].Cmd.Use != "on-cron" {
+		t.Fatalf("unexpected cron specs: %v", root.CronSpec)
+	}
+}
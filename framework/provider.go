@@ -1,5 +1,7 @@
 package framework
 
+import "context"
+
 type NewInstance func(...interface{}) (interface{}, error)
 
 // 定义一个服务提供者需要实现的接口
@@ -20,3 +22,45 @@ type ServiceProvider interface {
 	// Name 代表了这个服务提供者的凭证
 	Name() string
 }
+
+// ServiceProviderDepends 服务提供者可以选择实现这个接口，声明自己依赖的服务凭证
+// 服务容器会根据依赖关系计算实例化的顺序，保证依赖的服务先于自己实例化
+type ServiceProviderDepends interface {
+	// Depends 返回这个服务提供者依赖的服务凭证列表
+	Depends() []string
+}
+
+// providerDepends 获取服务提供者声明的依赖，没有实现 ServiceProviderDepends 则返回空
+func providerDepends(provider ServiceProvider) []string {
+	if d, ok := provider.(ServiceProviderDepends); ok {
+		return d.Depends()
+	}
+	return nil
+}
+
+// ServiceProviderScoped 服务提供者可以选择实现这个接口，IsScoped 返回 true 表示这是一个作用域内的服务
+// 作用域内的服务只能通过子作用域获取，在每个作用域（比如每个请求）中只实例化一次，作用域结束的时候被释放
+type ServiceProviderScoped interface {
+	IsScoped() bool
+}
+
+// isScoped 判断服务提供者是否为作用域内的服务
+func isScoped(provider ServiceProvider) bool {
+	if s, ok := provider.(ServiceProviderScoped); ok {
+		return s.IsScoped()
+	}
+	return false
+}
+
+// ServiceProviderTagged 服务提供者可以选择实现这个接口，绑定的时候服务凭证会被加入到返回的标签中
+// 同一个标签下的所有服务可以通过 MakeTagged 一次性获取
+type ServiceProviderTagged interface {
+	Tags() []string
+}
+
+// Shutdowner 服务实例或者服务提供者可以选择实现这个接口，在应用关闭的时候释放持有的资源
+// 比如关闭连接池，刷新并关闭日志文件等
+type Shutdowner interface {
+	// Shutdown 释放资源，需要在 ctx 超时之前返回
+	Shutdown(ctx context.Context) error
+}
@@ -2,8 +2,9 @@ package app
 
 import (
 	"errors"
-	"flag"
+	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/google/uuid"
 
@@ -124,6 +125,24 @@ func (h HadeApp) APPID() string {
 	return h.appId
 }
 
+// baseFolderArg 从命令行参数中读取 base_folder 参数，默认为当前路径
+// 不使用 flag.Parse，命令行中的其他参数（比如 --set）由 cobra 解析
+func baseFolderArg(args []string) string {
+	for i, arg := range args {
+		name := strings.TrimLeft(arg, "-")
+		if name == arg || arg == "--" {
+			continue
+		}
+		if strings.HasPrefix(name, "base_folder=") {
+			return strings.TrimPrefix(name, "base_folder=")
+		}
+		if name == "base_folder" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
 // NewHadeApp 初始化HadeApp
 func NewHadeApp(params ...interface{}) (interface{}, error) {
 	if len(params) != 2 {
@@ -135,8 +154,7 @@ func NewHadeApp(params ...interface{}) (interface{}, error) {
 	baseFolder := params[1].(string)
 	// 如果没有设置，则使用参数
 	if baseFolder == "" {
-		flag.StringVar(&baseFolder, "base_folder", "", "base_folder参数, 默认为当前路径")
-		flag.Parse()
+		baseFolder = baseFolderArg(os.Args[1:])
 	}
 	appId := uuid.New().String()
 	configMap := map[string]string{}
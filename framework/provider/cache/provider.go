@@ -47,3 +47,8 @@ func (h *HadeCacheProvider) Params(container framework.Container) []interface{}
 func (h *HadeCacheProvider) Name() string {
 	return contract.CacheKey
 }
+
+// Depends 缓存服务需要读取配置来决定使用的驱动
+func (h *HadeCacheProvider) Depends() []string {
+	return []string{contract.ConfigKey}
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// configLayer 一个配置来源中的所有配置文件
+type configLayer struct {
+	source  Source                            // 配置文件的来源
+	maps    map[string]map[string]interface{} // 配置文件结构，以文件名为 key，比如 database.yaml
+	raws    map[string][]byte                 // 配置文件的原始信息，以文件名为 key
+	secrets map[string]map[string]bool        // 配置文件中加密的配置项，以文件名为 key
+}
+
+// filesOf 返回目录中名称为 name 的配置文件，按照合并的顺序排列
+// 文件名中点越少越先合并，比如 app.yaml 在 app.local.yaml 之前，点的数量相同的时候按照文件名排序
+func (layer *configLayer) filesOf(name string) []string {
+	var files []string
+	for file := range layer.maps {
+		if configName(file) == name {
+			files = append(files, file)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		di, dj := strings.Count(files[i], "."), strings.Count(files[j], ".")
+		if di != dj {
+			return di < dj
+		}
+		return files[i] < files[j]
+	})
+	return files
+}
+
+// findFile 根据配置项的来源查找对应的配置来源和文件名，找不到的时候返回 nil
+func (conf *HadeConfig) findFile(path string) (*configLayer, string) {
+	for _, layer := range conf.layers {
+		for file := range layer.maps {
+			if layer.source.Path(file) == path {
+				return layer, file
+			}
+		}
+	}
+	return nil, ""
+}
+
+// merge 按照目录和文件的顺序重新合并名称为 name 的配置文件，并且重新记录每个配置项的来源，调用方需要持有写锁
+// 合并的结果保存在 rawMaps 中，需要调用 refresh 解析引用之后才能读取
+func (conf *HadeConfig) merge(name string) {
+	deleteSources(conf.sources, name)
+	var merged map[string]interface{}
+	for _, layer := range conf.layers {
+		for _, file := range layer.filesOf(name) {
+			if merged == nil {
+				merged = map[string]interface{}{}
+			}
+			mergeMap(merged, layer.maps[file], name, layer.source.Path(file), conf.sources)
+		}
+	}
+	merged = conf.applyOverrides(name, merged)
+	if merged == nil {
+		delete(conf.rawMaps, name)
+		return
+	}
+	conf.rawMaps[name] = merged
+}
+
+// mergeMap 将 src 深度合并到 dst 中，两边都是 map 的时候按照 key 合并，否则 src 中的值覆盖 dst 中的值
+func mergeMap(dst, src map[string]interface{}, prefix string, source string, sources map[string]string) {
+	for key, val := range src {
+		path := prefix + "." + key
+		srcMap, srcIsMap := val.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeMap(dstMap, srcMap, path, source, sources)
+			continue
+		}
+		deleteSources(sources, path)
+		if srcIsMap {
+			dstMap = map[string]interface{}{}
+			mergeMap(dstMap, srcMap, path, source, sources)
+			if len(srcMap) == 0 {
+				sources[path] = source
+			}
+			dst[key] = dstMap
+			continue
+		}
+		dst[key] = val
+		sources[path] = source
+	}
+}
+
+// deleteSources 删除 path 以及 path 下所有配置项的来源
+func deleteSources(sources map[string]string, path string) {
+	for key := range sources {
+		if key == path || strings.HasPrefix(key, path+".") {
+			delete(sources, key)
+		}
+	}
+}
+
+// Sources 返回 key 下每个配置项（叶子节点）的来源文件，key 为空的时候返回所有配置项的来源
+func (conf *HadeConfig) Sources(key string) map[string]string {
+	conf.lock.RLock()
+	defer conf.lock.RUnlock()
+	ret := map[string]string{}
+	for path, source := range conf.sources {
+		if key == "" || path == key || strings.HasPrefix(path, key+conf.keyDelim) {
+			ret[path] = source
+		}
+	}
+	return ret
+}
+
+// Locate 返回 key 所在的文件和行号，key 不存在的时候返回最近的上级配置项的位置
+func (conf *HadeConfig) Locate(key string) (string, int) {
+	conf.lock.RLock()
+	defer conf.lock.RUnlock()
+	path := strings.Split(key, conf.keyDelim)
+	for n := len(path); n >= 1; n-- {
+		prefix := strings.Join(path[:n], conf.keyDelim)
+		file, ok := conf.sources[prefix]
+		if !ok {
+			// 上级配置项使用排序之后的第一个下级配置项所在的文件
+			first := ""
+			for leaf, source := range conf.sources {
+				if strings.HasPrefix(leaf, prefix+conf.keyDelim) && (first == "" || leaf < first) {
+					first, file = leaf, source
+				}
+			}
+		}
+		if file == "" {
+			continue
+		}
+		layer, name := conf.findFile(file)
+		if layer == nil {
+			return file, 0
+		}
+		return file, lineOf(layer.raws[name], path[1:n])
+	}
+	return "", 0
+}
+
+// lineOf 在配置文件的内容中查找 path 所在的行，找不到的时候返回找到的最深的上级配置项所在的行
+// 依次匹配每一级的 key，支持 yaml、json、toml 以及在同一行中写完整路径的 properties
+func lineOf(raw []byte, path []string) int {
+	if len(path) == 0 {
+		return 0
+	}
+	found, seg := 0, 0
+	for i, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if matchKey(line, strings.Join(path[seg:], ".")) {
+			return i + 1
+		}
+		if matchKey(line, path[seg]) {
+			found, seg = i+1, seg+1
+			if seg == len(path) {
+				return found
+			}
+		}
+	}
+	return found
+}
+
+// matchKey 判断一行是否为 key 的定义，比如 key: 、"key": 、key = 或者 [key]
+func matchKey(line, key string) bool {
+	if line == "["+key+"]" {
+		return true
+	}
+	for _, prefix := range []string{key, `"` + key + `"`, "'" + key + "'"} {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		rest := strings.TrimSpace(line[len(prefix):])
+		if strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "=") {
+			return true
+		}
+	}
+	return false
+}
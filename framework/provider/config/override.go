@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// envOverridePrefix 覆盖配置的环境变量前缀，比如 HADE_APP__ADDRESS 覆盖 app.address
+	envOverridePrefix = "HADE_"
+	// envOverrideDelim 覆盖配置的环境变量中配置路径的分隔符
+	envOverrideDelim = "__"
+
+	// setOverrideSource 使用 Set 覆盖的配置项的来源
+	setOverrideSource = "set"
+)
+
+// configOverride 覆盖配置文件的配置项
+type configOverride struct {
+	key    string      // 完整的配置路径，比如 app.address
+	val    interface{} // 配置的值
+	source string      // 来源，环境变量为 env:变量名，Set 为 set
+}
+
+// envOverrides 返回环境变量中覆盖配置的配置项，环境变量名使用 __ 分隔配置路径并且转换为小写
+// 比如 HADE_DATABASE__DEFAULT__CONN_MAX_IDLE=20 覆盖 database.default.conn_max_idle
+func envOverrides(envMaps map[string]string) []configOverride {
+	var names []string
+	for name := range envMaps {
+		if strings.HasPrefix(name, envOverridePrefix) && strings.Contains(name, envOverrideDelim) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	overrides := make([]configOverride, 0, len(names))
+	for _, name := range names {
+		path := strings.Split(strings.ToLower(strings.TrimPrefix(name, envOverridePrefix)), envOverrideDelim)
+		overrides = append(overrides, configOverride{
+			key:    strings.Join(path, "."),
+			val:    scalarOf(envMaps[name]),
+			source: "env:" + name,
+		})
+	}
+	return overrides
+}
+
+// ParseOverride 解析命令行中 key=value 格式的配置，数字和布尔值会转换为对应的类型
+func ParseOverride(s string) (string, interface{}, error) {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return "", nil, fmt.Errorf("invalid config override %q, should be key=value", s)
+	}
+	return strings.TrimSpace(s[:i]), scalarOf(s[i+1:]), nil
+}
+
+// setArgs 从命令行参数中读取所有 --set key=value 格式的配置，格式错误的参数由命令行解析的时候报错
+// 不使用 flag.Parse，命令行中的其他参数由 cobra 解析
+func setArgs(args []string) []configOverride {
+	var overrides []configOverride
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		var set string
+		switch {
+		case strings.HasPrefix(name, "set="):
+			set = strings.TrimPrefix(name, "set=")
+		case name == "set" && i+1 < len(args):
+			set = args[i+1]
+		default:
+			continue
+		}
+		if key, val, err := ParseOverride(set); err == nil {
+			overrides = append(overrides, configOverride{key: key, val: val, source: setOverrideSource})
+		}
+	}
+	return overrides
+}
+
+// applyOverrides 将名称为 name 的覆盖配置合并到 merged 中，环境变量在前，Set 在后，调用方需要持有写锁
+func (conf *HadeConfig) applyOverrides(name string, merged map[string]interface{}) map[string]interface{} {
+	for _, o := range conf.overrides {
+		path := strings.Split(o.key, conf.keyDelim)
+		if path[0] != name {
+			continue
+		}
+		val := o.val
+		for i := len(path) - 1; i >= 1; i-- {
+			val = map[string]interface{}{path[i]: val}
+		}
+		src, ok := val.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if merged == nil {
+			merged = map[string]interface{}{}
+		}
+		mergeMap(merged, src, name, o.source, conf.sources)
+	}
+	return merged
+}
+
+// Set 在运行时覆盖 key 对应的配置，优先级高于环境变量和配置文件，订阅了 key 的 Watch 会收到通知
+func (conf *HadeConfig) Set(key string, val interface{}) error {
+	path := strings.Split(key, conf.keyDelim)
+	if _, ok := val.(map[string]interface{}); len(path) < 2 && !ok {
+		return fmt.Errorf("config key %s should be like file.key", key)
+	}
+
+	conf.lock.Lock()
+	defer conf.lock.Unlock()
+	olds := conf.overrides
+	overrides := make([]configOverride, 0, len(olds)+1)
+	for _, o := range olds {
+		if o.key != key || o.source != setOverrideSource {
+			overrides = append(overrides, o)
+		}
+	}
+	conf.overrides = append(overrides, configOverride{key: key, val: val, source: setOverrideSource})
+	conf.merge(path[0])
+	if err := conf.refresh(); err != nil {
+		// 解析引用失败的时候恢复原来的配置
+		conf.overrides = olds
+		conf.merge(path[0])
+		return err
+	}
+	return nil
+}
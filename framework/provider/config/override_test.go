@@ -0,0 +1,99 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yefangyong/go-frame/framework"
+)
+
+func TestHadeConfig_Overrides(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	base, env := filepath.Join(folder, "base"), filepath.Join(folder, "testing")
+	_ = os.Mkdir(base, 0755)
+	_ = os.Mkdir(env, 0755)
+	writeFile(t, filepath.Join(base, "app.yaml"), "address: :8000\nname: base\nswagger: true\n")
+	writeFile(t, filepath.Join(env, "app.yaml"), "address: :8001\nname: testing\n")
+
+	envs := map[string]string{"HADE_APP__ADDRESS": ":8002", "HADE_CACHE__REDIS__DB": "3"}
+	ins, err := NewHadeConfig(framework.NewHadeContainer(), []string{base, env}, envs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := ins.(*HadeConfig)
+	conf.debounce = 10 * time.Millisecond
+
+	if got := conf.GetString("app.address"); got != ":8002" {
+		t.Fatalf("app.address = %s, env should override files", got)
+	}
+	if got := conf.GetString("app.name"); got != "testing" {
+		t.Fatalf("app.name = %s, env folder should override base", got)
+	}
+	if got := conf.Get("cache.redis.db"); got != 3 {
+		t.Fatalf("cache.redis.db = %#v, config without file can be set by env", got)
+	}
+
+	changed := make(chan interface{}, 1)
+	conf.Watch("app.address", func(oldVal, newVal interface{}) { changed <- newVal })
+	if err := conf.Set("app.address", ":8003"); err != nil {
+		t.Fatal(err)
+	}
+	if got := conf.GetString("app.address"); got != ":8003" {
+		t.Fatalf("app.address = %s, Set should override env", got)
+	}
+	if got := conf.Sources("app.address")["app.address"]; got != "set" {
+		t.Fatalf("source = %s", got)
+	}
+	select {
+	case newVal := <-changed:
+		if newVal != ":8003" {
+			t.Fatalf("watcher got %v", newVal)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("watcher should be notified after Set")
+	}
+
+	if err := conf.Set("app.name", "${app.missing}"); err == nil {
+		t.Fatal("Set with invalid reference should fail")
+	}
+	if got := conf.GetString("app.name"); got != "testing" {
+		t.Fatalf("app.name = %s, failed Set should be reverted", got)
+	}
+}
+
+func TestHadeConfig_SetArgs(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	writeFile(t, filepath.Join(folder, "app.yaml"), "address: :8000\nname: hade\n")
+
+	// 创建配置服务的时候已经使用命令行中的 --set 覆盖配置
+	args := os.Args
+	defer func() { os.Args = args }()
+	os.Args = []string{"hade", "app", "start", "--set", "app.address=:9000", "--set=log.level=error", "--set", "invalid", "--", "--set", "app.name=ignored"}
+
+	envs := map[string]string{"HADE_APP__ADDRESS": ":8002"}
+	ins, err := NewHadeConfig(framework.NewHadeContainer(), []string{folder}, envs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := ins.(*HadeConfig)
+	if got := conf.GetString("app.address"); got != ":9000" {
+		t.Fatalf("app.address = %s, --set should override env", got)
+	}
+	if got := conf.GetString("log.level"); got != "error" {
+		t.Fatalf("log.level = %s", got)
+	}
+	if got := conf.GetString("app.name"); got != "hade" {
+		t.Fatalf("app.name = %s, args after -- should be ignored", got)
+	}
+}
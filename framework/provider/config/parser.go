@@ -0,0 +1,165 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/ghodss/yaml"
+	"github.com/magiconair/properties"
+	"github.com/pelletier/go-toml"
+	"github.com/pkg/errors"
+)
+
+// Parser 将配置文件的内容解析为配置结构
+type Parser func(content []byte) (map[string]interface{}, error)
+
+var (
+	// parsers 配置文件扩展名对应的解析器
+	parsers = map[string]Parser{
+		".yaml":       parseYAML,
+		".yml":        parseYAML,
+		".json":       parseJSON,
+		".toml":       parseTOML,
+		".properties": parseProperties,
+	}
+	parserLock sync.RWMutex
+)
+
+// RegisterParser 注册扩展名对应的配置文件解析器，ext 带有点号，比如 .ini
+func RegisterParser(ext string, parser Parser) {
+	parserLock.Lock()
+	defer parserLock.Unlock()
+	parsers[strings.ToLower(ext)] = parser
+}
+
+// findParser 根据文件的扩展名查找解析器，不支持的文件返回 nil
+func findParser(file string) Parser {
+	parserLock.RLock()
+	defer parserLock.RUnlock()
+	return parsers[strings.ToLower(filepath.Ext(file))]
+}
+
+// ReadFolders 按照顺序读取并深度合并目录中的配置文件，以配置名称为 key，不存在的目录会被忽略
+// 和配置服务不同，这里不替换环境变量、不解密也不解析引用，用于根据配置文件生成代码等场景
+func ReadFolders(folders ...string) (map[string]interface{}, error) {
+	ret := map[string]interface{}{}
+	for _, folder := range folders {
+		files, err := ioutil.ReadDir(folder)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		layer := &configLayer{maps: map[string]map[string]interface{}{}}
+		for _, file := range files {
+			parser := findParser(file.Name())
+			if file.IsDir() || parser == nil {
+				continue
+			}
+			content, err := ioutil.ReadFile(filepath.Join(folder, file.Name()))
+			if err != nil {
+				return nil, err
+			}
+			c, err := parser(content)
+			if err != nil {
+				return nil, errors.Wrap(err, "parse config file "+filepath.Join(folder, file.Name()))
+			}
+			layer.maps[file.Name()] = c
+		}
+		names := map[string]bool{}
+		for file := range layer.maps {
+			names[configName(file)] = true
+		}
+		for name := range names {
+			merged, _ := ret[name].(map[string]interface{})
+			if merged == nil {
+				merged = map[string]interface{}{}
+			}
+			for _, file := range layer.filesOf(name) {
+				mergeMap(merged, layer.maps[file], name, file, map[string]string{})
+			}
+			ret[name] = merged
+		}
+	}
+	return ret, nil
+}
+
+// configName 配置文件对应的配置名称，为文件名第一个点之前的部分，比如 app.local.yaml 对应 app
+func configName(file string) string {
+	if i := strings.Index(file, "."); i >= 0 {
+		return file[:i]
+	}
+	return file
+}
+
+func parseYAML(content []byte) (map[string]interface{}, error) {
+	c := map[string]interface{}{}
+	if err := yaml.Unmarshal(content, &c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func parseJSON(content []byte) (map[string]interface{}, error) {
+	c := map[string]interface{}{}
+	if len(strings.TrimSpace(string(content))) == 0 {
+		return c, nil
+	}
+	if err := json.Unmarshal(content, &c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func parseTOML(content []byte) (map[string]interface{}, error) {
+	tree, err := toml.LoadBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	return tree.ToMap(), nil
+}
+
+// parseProperties 解析 Java 风格的 properties 文件，使用点分隔的 key 转换为嵌套的结构
+func parseProperties(content []byte) (map[string]interface{}, error) {
+	// 关闭 ${} 的展开，和其他格式一样只做 env() 的替换
+	loader := &properties.Loader{Encoding: properties.UTF8, DisableExpansion: true}
+	p, err := loader.LoadBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	c := map[string]interface{}{}
+	for _, key := range p.Keys() {
+		val, _ := p.Get(key)
+		if err := setPath(c, strings.Split(key, "."), val); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// setPath 按照路径在 maps 中设置值，路径中间的节点不是 map 的时候返回错误
+func setPath(maps map[string]interface{}, path []string, val interface{}) error {
+	for i, key := range path[:len(path)-1] {
+		next, ok := maps[key]
+		if !ok {
+			next = map[string]interface{}{}
+			maps[key] = next
+		}
+		nextMap, ok := next.(map[string]interface{})
+		if !ok {
+			return errors.New("key " + strings.Join(path[:i+1], ".") + " is not a map")
+		}
+		maps = nextMap
+	}
+	last := path[len(path)-1]
+	if _, ok := maps[last].(map[string]interface{}); ok {
+		return errors.New("key " + strings.Join(path, ".") + " is a map")
+	}
+	maps[last] = val
+	return nil
+}
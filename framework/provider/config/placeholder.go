@@ -0,0 +1,191 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cast"
+)
+
+var (
+	// envPattern 匹配 env(KEY)、env(KEY, default) 和 env(KEY!)
+	envPattern = regexp.MustCompile(`env\(\s*([A-Za-z_][A-Za-z0-9_.]*)\s*(!)?\s*(?:,([^)]*))?\)`)
+	// refPattern 匹配 ${app.name}，$${app.name} 为转义，结果为 ${app.name}
+	refPattern = regexp.MustCompile(`\$?\$\{([^{}]*)\}`)
+)
+
+// expandEnv 替换配置文件中所有字符串值里面的环境变量，只替换值，不会影响配置文件的格式
+// 环境变量不存在的时候使用默认值，没有默认值的时候为空字符串，使用 env(KEY!) 的时候返回错误
+func expandEnv(val interface{}, path string, envMaps map[string]string) (interface{}, error) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			expanded, err := expandEnv(child, joinKey(path, key), envMaps)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = expanded
+		}
+		return v, nil
+	case []interface{}:
+		for i, child := range v {
+			expanded, err := expandEnv(child, joinKey(path, strconv.Itoa(i)), envMaps)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+		return v, nil
+	case string:
+		var missing string
+		ret := envPattern.ReplaceAllStringFunc(v, func(match string) string {
+			m := envPattern.FindStringSubmatch(match)
+			if env, ok := envMaps[m[1]]; ok {
+				// 环境变量中的 ${ 不作为引用解析
+				return strings.ReplaceAll(env, "${", "$${")
+			}
+			if m[2] != "" && missing == "" {
+				missing = m[1]
+			}
+			return strings.TrimSpace(m[3])
+		})
+		if missing != "" {
+			return nil, fmt.Errorf("%s: environment variable %s is required", path, missing)
+		}
+		if envPattern.FindString(v) == v {
+			// 整个值为一个环境变量的时候，和直接写在配置文件中一样识别数字和布尔值
+			return scalarOf(ret), nil
+		}
+		return ret, nil
+	default:
+		return val, nil
+	}
+}
+
+// scalarOf 将字符串转换为对应的整数或者布尔值，不能无损转换的时候保持为字符串
+func scalarOf(s string) interface{} {
+	if s == "true" || s == "false" {
+		return s == "true"
+	}
+	if n, err := strconv.Atoi(s); err == nil && strconv.Itoa(n) == s {
+		return n
+	}
+	return s
+}
+
+// refResolver 解析配置中的 ${key} 引用
+type refResolver struct {
+	raw       map[string]interface{} // 合并之后没有解析引用的配置
+	done      map[string]interface{} // 已经解析完成的配置项
+	resolving []string               // 正在解析的配置项，用于检测循环引用
+	secrets   map[string]bool        // 加密的配置项，引用了加密配置的配置项也会加入
+}
+
+// resolveRefs 返回解析了所有 ${key} 引用的配置，raw 不会被修改
+// 只引用一个配置项的时候保持原来的类型，和其他文本拼接的时候转换为字符串
+// 引用了加密配置的配置项会加入 secrets，这样它的值也会作为加密配置隐藏
+func resolveRefs(raw map[string]interface{}, secrets map[string]bool) (map[string]interface{}, error) {
+	r := &refResolver{raw: raw, done: map[string]interface{}{}, secrets: secrets}
+	ret := make(map[string]interface{}, len(raw))
+	for name := range raw {
+		val, err := r.resolvePath(name)
+		if err != nil {
+			return nil, err
+		}
+		ret[name] = val
+	}
+	return ret, nil
+}
+
+func (r *refResolver) resolvePath(path string) (interface{}, error) {
+	if val, ok := r.done[path]; ok {
+		return val, nil
+	}
+	for i, p := range r.resolving {
+		if p == path {
+			return nil, fmt.Errorf("config reference cycle: %s -> %s", strings.Join(r.resolving[i:], " -> "), path)
+		}
+	}
+	val := searchMap(r.raw, strings.Split(path, "."))
+	if val == nil {
+		return nil, nil
+	}
+
+	r.resolving = append(r.resolving, path)
+	ret, err := r.resolveValue(path, val)
+	r.resolving = r.resolving[:len(r.resolving)-1]
+	if err != nil {
+		return nil, err
+	}
+	r.done[path] = ret
+	return ret, nil
+}
+
+func (r *refResolver) resolveValue(path string, val interface{}) (interface{}, error) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for key := range v {
+			child, err := r.resolvePath(path + "." + key)
+			if err != nil {
+				return nil, err
+			}
+			ret[key] = child
+		}
+		return ret, nil
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, item := range v {
+			child, err := r.resolveValue(path+"."+strconv.Itoa(i), item)
+			if err != nil {
+				return nil, err
+			}
+			ret[i] = child
+		}
+		return ret, nil
+	case string:
+		return r.resolveString(path, v)
+	default:
+		return val, nil
+	}
+}
+
+func (r *refResolver) resolveString(path string, s string) (interface{}, error) {
+	matches := refPattern.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s, nil
+	}
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		sb.WriteString(s[last:m[0]])
+		last = m[1]
+		if strings.HasPrefix(s[m[0]:], "$$") {
+			sb.WriteString(s[m[0]+1 : m[1]])
+			continue
+		}
+		key := strings.TrimSpace(s[m[2]:m[3]])
+		val, err := r.resolvePath(key)
+		if err != nil {
+			return nil, err
+		}
+		if val == nil {
+			return nil, fmt.Errorf("%s: referenced config %s not exist", path, key)
+		}
+		if hasSecret(r.secrets, key) {
+			r.secrets[path] = true
+		}
+		if m[0] == 0 && m[1] == len(s) {
+			return val, nil
+		}
+		str, err := cast.ToStringE(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s: referenced config %s is not a scalar value", path, key)
+		}
+		sb.WriteString(str)
+	}
+	sb.WriteString(s[last:])
+	return sb.String(), nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yefangyong/go-frame/framework"
+)
+
+func TestHadeConfig_Placeholder(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	writeFile(t, filepath.Join(folder, "app.yaml"), "name: hade\nport: env(PORT)\nhost: env(HOST, 127.0.0.1)\nsecret: env(SECRET)\n")
+	writeFile(t, filepath.Join(folder, "database.yaml"), "prefix: ${app.name}_\nmaster:\n  port: ${app.port}\nreplica: ${database.master}\nraw: $${app.name}\n")
+
+	envs := map[string]string{"PORT": "3306", "SECRET": "a\"b\nc: ${app.name}"}
+	ins, err := NewHadeConfig(framework.NewHadeContainer(), []string{folder}, envs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := ins.(*HadeConfig)
+
+	if got := conf.Get("app.port"); got != 3306 {
+		t.Fatalf("app.port = %#v, want int", got)
+	}
+	if got := conf.GetString("app.host"); got != "127.0.0.1" {
+		t.Fatalf("app.host = %s, want default value", got)
+	}
+	if got := conf.GetString("app.secret"); got != envs["SECRET"] {
+		t.Fatalf("app.secret = %q, want %q", got, envs["SECRET"])
+	}
+	if got := conf.GetString("database.prefix"); got != "hade_" {
+		t.Fatalf("database.prefix = %s", got)
+	}
+	if got := conf.GetInt("database.replica.port"); got != 3306 {
+		t.Fatalf("database.replica.port = %d", got)
+	}
+	if got := conf.GetString("database.raw"); got != "${app.name}" {
+		t.Fatalf("database.raw = %s", got)
+	}
+}
+
+func TestHadeConfig_PlaceholderError(t *testing.T) {
+	cases := map[string]string{
+		"app.yaml: password: env(DB_PASSWORD!)\n": "DB_PASSWORD is required",
+		"app.yaml: a: ${app.b}\nb: ${app.a}\n":    "config reference cycle",
+		"app.yaml: a: ${app.missing}\n":           "app.missing not exist",
+	}
+	for content, want := range cases {
+		folder, err := ioutil.TempDir("", "hade-config")
+		if err != nil {
+			t.Fatal(err)
+		}
+		parts := strings.SplitN(content, ": ", 2)
+		writeFile(t, filepath.Join(folder, parts[0]), parts[1])
+		_, err = NewHadeConfig(framework.NewHadeContainer(), []string{folder}, map[string]string{})
+		os.RemoveAll(folder)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("err = %v, want %q", err, want)
+		}
+	}
+}
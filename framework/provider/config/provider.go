@@ -8,6 +8,7 @@ import (
 )
 
 type HadeConfigProvider struct {
+	Sources []Source // 本地配置目录之后加载的配置来源，比如远程的配置中心
 }
 
 func (h *HadeConfigProvider) Register(container framework.Container) framework.NewInstance {
@@ -27,10 +28,23 @@ func (h *HadeConfigProvider) Params(container framework.Container) []interface{}
 	envService := container.MustMake(contract.EnvKey).(contract.Env)
 	env := envService.AppEnv()
 	configFolder := appService.ConfigFolder()
-	envFolder := filepath.Join(configFolder, env)
-	return []interface{}{container, envFolder, envService.All()}
+	// 先加载 base 目录中的公共配置，再使用当前环境目录中的配置覆盖
+	folders := []string{filepath.Join(configFolder, "base"), filepath.Join(configFolder, env)}
+	// 设置了远程配置中心的时候，远程的配置覆盖本地的配置
+	sources := append([]Source{}, h.Sources...)
+	if url := envService.Get(RemoteEnv); url != "" {
+		source := NewHTTPSource(url)
+		source.SetRequired(envService.GetBool(RemoteRequiredEnv, false))
+		sources = append(sources, source)
+	}
+	return []interface{}{container, folders, envService.All(), sources}
 }
 
 func (h *HadeConfigProvider) Name() string {
 	return contract.ConfigKey
 }
+
+// Depends 配置服务需要通过 app 服务获取配置目录，通过 env 服务替换环境变量
+func (h *HadeConfigProvider) Depends() []string {
+	return []string{contract.AppKey, contract.EnvKey}
+}
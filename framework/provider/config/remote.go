@@ -0,0 +1,311 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+const (
+	// RemoteEnv 远程配置中心地址的环境变量，设置之后远程的配置文件覆盖本地的配置文件
+	RemoteEnv = "HADE_CONFIG_REMOTE"
+	// RemoteRequiredEnv 为 true 的时候启动时读取远程配置失败返回错误，默认只使用本地的配置启动，之后在后台重试
+	RemoteRequiredEnv = "HADE_CONFIG_REMOTE_REQUIRED"
+
+	defaultRemoteWait  = 30 * time.Second
+	defaultRemoteRetry = 5 * time.Second
+	maxRemoteWait      = 5 * time.Minute
+)
+
+// HTTPSource 通过 HTTP 长轮询读取远程的配置文件
+//
+// 请求 GET {url}?wait=30s 的时候带上 If-None-Match，配置没有变化的时候服务端最多等待 wait 之后返回 304，
+// 有变化的时候返回 200 和新的 ETag，响应的内容为一个 YAML 文档，key 为文件名，value 为文件的内容，比如：
+//
+//	app.yaml: |
+//	  name: hade
+//	database.yaml: |
+//	  default:
+//	    host: 127.0.0.1
+type HTTPSource struct {
+	url      string
+	wait     time.Duration // 长轮询的等待时间
+	retry    time.Duration // 请求失败之后重试的间隔
+	required bool          // Load 失败的时候是否返回错误
+	client   *http.Client
+
+	ctx    context.Context // Close 的时候取消，停止长轮询
+	cancel context.CancelFunc
+
+	lock  sync.Mutex
+	etag  string
+	files map[string][]byte // 最近一次读取的配置文件
+}
+
+// NewHTTPSource 创建远程配置来源，url 为配置服务的地址，比如 http://127.0.0.1:8070
+func NewHTTPSource(url string) *HTTPSource {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &HTTPSource{
+		url:    strings.TrimSuffix(url, "/"),
+		wait:   defaultRemoteWait,
+		retry:  defaultRemoteRetry,
+		client: &http.Client{Timeout: defaultRemoteWait + 10*time.Second},
+		ctx:    ctx,
+		cancel: cancel,
+		files:  map[string][]byte{},
+	}
+}
+
+// SetWait 设置长轮询的等待时间
+func (s *HTTPSource) SetWait(wait time.Duration) {
+	s.wait = wait
+	s.client.Timeout = wait + 10*time.Second
+}
+
+// SetRequired 设置 Load 失败的时候是否返回错误，默认为 false，使用最近一次读取的配置，Watch 会在后台重试
+func (s *HTTPSource) SetRequired(required bool) {
+	s.required = required
+}
+
+func (s *HTTPSource) Path(file string) string {
+	return s.url + "/" + file
+}
+
+func (s *HTTPSource) Load() (map[string][]byte, error) {
+	files, _, err := s.fetch(0)
+	if err == nil {
+		return files, nil
+	}
+	if s.required {
+		return nil, err
+	}
+	// 远程配置不可用的时候不影响启动，使用最近一次读取的配置，读取成功之后通过 Watch 更新
+	log.Println("读取远程配置失败，使用最近一次读取的配置：", err)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	files = make(map[string][]byte, len(s.files))
+	for file, content := range s.files {
+		files[file] = content
+	}
+	return files, nil
+}
+
+func (s *HTTPSource) Watch(onChange func(files map[string][]byte)) error {
+	go func() {
+		for {
+			_, changed, err := s.fetch(s.wait)
+			if s.ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				log.Println("读取远程配置失败：", err)
+				select {
+				case <-time.After(s.retry):
+				case <-s.ctx.Done():
+					return
+				}
+				continue
+			}
+			if len(changed) > 0 {
+				log.Println("远程配置更新：", strings.Join(sortedFiles(changed), ", "))
+				onChange(changed)
+			}
+		}
+	}()
+	return nil
+}
+
+// Close 停止 Watch 中的长轮询，正在等待的请求会被取消
+func (s *HTTPSource) Close() error {
+	s.cancel()
+	return nil
+}
+
+// fetch 请求远程配置，返回所有的配置文件以及和上一次相比有变化的文件，删除的文件内容为 nil
+// wait 大于 0 的时候使用长轮询等待配置变化
+func (s *HTTPSource) fetch(wait time.Duration) (map[string][]byte, map[string][]byte, error) {
+	s.lock.Lock()
+	etag := s.etag
+	s.lock.Unlock()
+
+	url := s.url
+	if wait > 0 {
+		url += "?wait=" + wait.String()
+	}
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, errors.WithStack(err)
+	}
+	if etag != "" && wait > 0 {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "request remote config")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("request remote config %s: %s", s.url, resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "read remote config")
+	}
+	docs := map[string]string{}
+	if err := yaml.Unmarshal(body, &docs); err != nil {
+		return nil, nil, errors.Wrap(err, "parse remote config "+s.url)
+	}
+
+	files := make(map[string][]byte, len(docs))
+	for file, content := range docs {
+		files[file] = []byte(content)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	changed := map[string][]byte{}
+	for file, content := range files {
+		if old, ok := s.files[file]; !ok || string(old) != string(content) {
+			changed[file] = content
+		}
+	}
+	for file := range s.files {
+		if _, ok := files[file]; !ok {
+			changed[file] = nil
+		}
+	}
+	s.files = files
+	s.etag = resp.Header.Get("ETag")
+	return files, changed, nil
+}
+
+func sortedFiles(files map[string][]byte) []string {
+	ret := make([]string, 0, len(files))
+	for file := range files {
+		ret = append(ret, file)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// RemoteServer HTTPSource 对应的配置服务，保存在内存中，用于在本地测试远程配置
+// GET / 返回所有的配置文件，GET /{file} 返回单个配置文件的内容
+type RemoteServer struct {
+	lock    sync.Mutex
+	files   map[string][]byte
+	etag    string
+	changed chan struct{} // 配置变化的时候关闭，唤醒等待中的长轮询请求
+}
+
+// NewRemoteServer 创建一个没有配置文件的配置服务
+func NewRemoteServer() *RemoteServer {
+	s := &RemoteServer{files: map[string][]byte{}, changed: make(chan struct{})}
+	s.etag = s.computeEtag()
+	return s
+}
+
+// Set 增加或者修改配置文件，content 为 nil 的时候删除配置文件
+func (s *RemoteServer) Set(file string, content []byte) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if content == nil {
+		delete(s.files, file)
+	} else {
+		s.files[file] = content
+	}
+	etag := s.computeEtag()
+	if etag == s.etag {
+		return
+	}
+	s.etag = etag
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// computeEtag 根据所有配置文件的内容计算 ETag，调用方需要持有锁
+func (s *RemoteServer) computeEtag() string {
+	h := sha256.New()
+	for _, file := range sortedFiles(s.files) {
+		fmt.Fprintf(h, "%s\x00%d\x00", file, len(s.files[file]))
+		h.Write(s.files[file])
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil))[:16] + `"`
+}
+
+func (s *RemoteServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if file := strings.TrimPrefix(r.URL.Path, "/"); file != "" {
+		s.lock.Lock()
+		content, ok := s.files[file]
+		s.lock.Unlock()
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(content)
+		return
+	}
+
+	var wait time.Duration
+	if v := r.URL.Query().Get("wait"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			http.Error(w, "invalid wait", http.StatusBadRequest)
+			return
+		}
+		if d > maxRemoteWait {
+			d = maxRemoteWait
+		}
+		wait = d
+	}
+
+	// 客户端的配置已经是最新的时候等待配置变化
+	s.lock.Lock()
+	etag, changed := s.etag, s.changed
+	s.lock.Unlock()
+	if match := r.Header.Get("If-None-Match"); match == etag {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-changed:
+		case <-timer.C:
+			w.Header().Set("ETag", etag)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	s.lock.Lock()
+	docs := make(map[string]string, len(s.files))
+	for file, content := range s.files {
+		docs[file] = string(content)
+	}
+	etag = s.etag
+	s.lock.Unlock()
+	body, err := yaml.Marshal(docs)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Write(body)
+}
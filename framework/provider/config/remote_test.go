@@ -0,0 +1,148 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yefangyong/go-frame/framework"
+)
+
+func TestHadeConfig_Remote(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	writeFile(t, filepath.Join(folder, "app.yaml"), "name: local\nport: 80\n")
+
+	server := NewRemoteServer()
+	server.Set("app.yaml", []byte("name: remote\n"))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	source := NewHTTPSource(ts.URL)
+	source.SetWait(200 * time.Millisecond)
+
+	ins, err := NewHadeConfig(framework.NewHadeContainer(), []string{folder}, map[string]string{}, []Source{source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := ins.(*HadeConfig)
+	conf.debounce = 10 * time.Millisecond
+	if got := conf.GetString("app.name"); got != "remote" {
+		t.Fatalf("app.name = %s, remote should override local", got)
+	}
+	if got := conf.GetInt("app.port"); got != 80 {
+		t.Fatalf("app.port = %d, want local value", got)
+	}
+	if got := conf.Sources("app.name")["app.name"]; got != ts.URL+"/app.yaml" {
+		t.Fatalf("source = %s", got)
+	}
+
+	changed := make(chan interface{}, 1)
+	conf.Watch("cache.driver", func(oldVal, newVal interface{}) {
+		changed <- newVal
+	})
+	server.Set("cache.yaml", []byte("driver: redis\n"))
+	select {
+	case val := <-changed:
+		if val != "redis" {
+			t.Fatalf("cache.driver = %v", val)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("remote change not notified")
+	}
+
+	// 删除远程的配置文件之后恢复为本地的配置
+	server.Set("app.yaml", nil)
+	deadline := time.Now().Add(3 * time.Second)
+	for conf.GetString("app.name") != "local" {
+		if time.Now().After(deadline) {
+			t.Fatal("config not reloaded after remote file removed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestHadeConfig_RemoteUnavailable(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	writeFile(t, filepath.Join(folder, "app.yaml"), "name: local\n")
+
+	// 远程配置不可用的时候使用本地配置启动，恢复之后在后台读取
+	server := NewRemoteServer()
+	server.Set("app.yaml", []byte("name: remote\n"))
+	ts := httptest.NewUnstartedServer(server)
+	addr := ts.Listener.Addr().String()
+	ts.Listener.Close()
+	source := NewHTTPSource("http://" + addr)
+	source.SetWait(200 * time.Millisecond)
+	source.retry = 20 * time.Millisecond
+
+	ins, err := NewHadeConfig(framework.NewHadeContainer(), []string{folder}, map[string]string{}, []Source{source})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := ins.(*HadeConfig)
+	if got := conf.GetString("app.name"); got != "local" {
+		t.Fatalf("app.name = %s, want local value", got)
+	}
+	if ts.Listener, err = net.Listen("tcp", addr); err != nil {
+		t.Fatal(err)
+	}
+	ts.Start()
+	defer ts.Close()
+	deadline := time.Now().Add(3 * time.Second)
+	for conf.GetString("app.name") != "remote" {
+		if time.Now().After(deadline) {
+			t.Fatal("remote config not loaded after it becomes available")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err := conf.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// 设置为必须的时候启动失败
+	required := NewHTTPSource("http://127.0.0.1:1")
+	required.SetRequired(true)
+	if _, err := NewHadeConfig(framework.NewHadeContainer(), []string{folder}, map[string]string{}, []Source{required}); err == nil {
+		t.Fatal("unavailable required remote config should fail")
+	}
+}
+
+func TestHTTPSource_Close(t *testing.T) {
+	server := NewRemoteServer()
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	source := NewHTTPSource(ts.URL)
+	source.SetWait(time.Minute)
+	if _, err := source.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := make(chan struct{}, 1)
+	if err := source.Watch(func(files map[string][]byte) { changed <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+	// 关闭之后等待中的长轮询被取消，之后的变化不再通知
+	time.Sleep(50 * time.Millisecond)
+	if err := source.Close(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	server.Set("app.yaml", []byte("name: remote\n"))
+	select {
+	case <-changed:
+		t.Fatal("closed source should not notify changes")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/cast"
+)
+
+// Schema 注册的配置结构，hade config validate 会使用它检查配置
+type Schema struct {
+	// Key 配置路径，以 .* 结尾的时候检查路径下的每一个子配置，比如 database.* 检查每一个数据库连接
+	Key string
+	// Target 配置结构的指针，比如 (*contract.DBConfig)(nil)
+	Target interface{}
+}
+
+var (
+	schemas    []Schema
+	schemaLock sync.RWMutex
+
+	validate = newValidator()
+)
+
+// RegisterSchema 注册配置路径对应的配置结构，同一个配置路径只能注册一次
+func RegisterSchema(key string, target interface{}) {
+	t := reflect.TypeOf(target)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		panic("config: RegisterSchema expects a pointer to struct, got " + fmt.Sprint(t))
+	}
+	schemaLock.Lock()
+	defer schemaLock.Unlock()
+	for _, s := range schemas {
+		if s.Key == key {
+			panic("config: schema of " + key + " is already registered")
+		}
+	}
+	schemas = append(schemas, Schema{Key: key, Target: target})
+}
+
+// Schemas 返回所有注册的配置结构，按照配置路径排序
+func Schemas() []Schema {
+	schemaLock.RLock()
+	defer schemaLock.RUnlock()
+	ret := append([]Schema{}, schemas...)
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Key < ret[j].Key
+	})
+	return ret
+}
+
+// FieldError 配置项的错误
+type FieldError struct {
+	Key     string // 配置项的完整路径，比如 database.default.port
+	Message string
+}
+
+// ValidationErrors 配置检查的所有错误
+type ValidationErrors []FieldError
+
+func (errs ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Key+": "+e.Message)
+	}
+	return "invalid config: " + strings.Join(msgs, "; ")
+}
+
+// Decode 将 input 解析到 val 中，input 中没有的配置项使用 default 标签中的默认值，然后使用 validate 标签检查
+// key 是 input 的配置路径，用于生成错误信息；strict 为 true 的时候结构中没有的配置项也会作为错误返回
+// 检查不通过的时候返回 ValidationErrors
+func Decode(key string, input interface{}, val interface{}, strict bool) error {
+	var metadata mapstructure.Metadata
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		TagName:          "yaml",
+		Result:           val,
+		Metadata:         &metadata,
+		WeaklyTypedInput: true,
+		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := decoder.Decode(input); err != nil {
+		return err
+	}
+
+	v := reflect.ValueOf(val)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	if err := setDefaults(v.Elem(), input); err != nil {
+		return err
+	}
+
+	var errs ValidationErrors
+	if strict {
+		sort.Strings(metadata.Unused)
+		for _, unused := range metadata.Unused {
+			errs = append(errs, FieldError{Key: joinKey(key, unused), Message: "unknown config key"})
+		}
+	}
+	if err := validate.Struct(val); err != nil {
+		fieldErrs, ok := err.(validator.ValidationErrors)
+		if !ok {
+			return err
+		}
+		for _, fe := range fieldErrs {
+			errs = append(errs, FieldError{Key: joinKey(key, fieldPath(fe.Namespace())), Message: fieldMessage(fe)})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// newValidator 创建使用 yaml 标签作为字段名的检查器，并且注册 duration 检查
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("yaml"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	_ = v.RegisterValidation("duration", func(fl validator.FieldLevel) bool {
+		_, err := time.ParseDuration(fl.Field().String())
+		return err == nil
+	})
+	return v
+}
+
+// fieldPath 去掉检查器命名空间中的结构名称，比如 DBConfig.port 返回 port
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	rule := fe.Tag()
+	if fe.Param() != "" {
+		rule += "=" + fe.Param()
+	}
+	if fe.Tag() == "required" {
+		return "is required"
+	}
+	return fmt.Sprintf("value %v does not satisfy %s", fe.Value(), rule)
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// setDefaults 为 input 中没有对应配置项并且带有 default 标签的字段设置默认值，嵌套的结构也会设置
+// 配置中显式设置的值即使是零值也会保留，比如 enabled: false 或者 port: 0；调用方预先设置的非零值也不会被覆盖
+func setDefaults(v reflect.Value, input interface{}) error {
+	present := inputKeys(input)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field, fv := t.Field(i), v.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.ToLower(strings.SplitN(field.Tag.Get("yaml"), ",", 2)[0])
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		sub, ok := present[name]
+		if fv.Kind() == reflect.Struct {
+			if err := setDefaults(fv, sub); err != nil {
+				return err
+			}
+			continue
+		}
+		if fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct {
+			if err := setDefaults(fv.Elem(), sub); err != nil {
+				return err
+			}
+			continue
+		}
+		def, hasDef := field.Tag.Lookup("default")
+		if !hasDef || ok || !fv.IsZero() {
+			continue
+		}
+		if err := setValue(fv, def); err != nil {
+			return fmt.Errorf("default value %q of field %s: %v", def, field.Name, err)
+		}
+	}
+	return nil
+}
+
+// inputKeys 返回 input 中的配置项，key 转换为小写，和 mapstructure 一样不区分大小写
+func inputKeys(input interface{}) map[string]interface{} {
+	ret := map[string]interface{}{}
+	m := reflect.ValueOf(input)
+	if m.Kind() != reflect.Map {
+		return ret
+	}
+	iter := m.MapRange()
+	for iter.Next() {
+		ret[strings.ToLower(fmt.Sprint(iter.Key().Interface()))] = iter.Value().Interface()
+	}
+	return ret
+}
+
+// setValue 将字符串形式的默认值转换为字段的类型，切片使用逗号分隔
+func setValue(fv reflect.Value, def string) error {
+	if fv.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(def)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	}
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(def)
+	case reflect.Bool:
+		b, err := cast.ToBoolE(def)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := cast.ToInt64E(def)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := cast.ToUint64E(def)
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := cast.ToFloat64E(def)
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", fv.Type())
+		}
+		parts := strings.Split(def, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+		}
+		fv.Set(reflect.ValueOf(parts).Convert(fv.Type()))
+	default:
+		return fmt.Errorf("unsupported type %s", fv.Type())
+	}
+	return nil
+}
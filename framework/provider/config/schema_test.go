@@ -0,0 +1,111 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yefangyong/go-frame/framework"
+)
+
+type testServerConfig struct {
+	Host    string        `yaml:"host" default:"127.0.0.1"`
+	Port    int           `yaml:"port" default:"8080" validate:"min=1,max=65535"`
+	Timeout time.Duration `yaml:"timeout" default:"3s"`
+	Mode    string        `yaml:"mode" validate:"omitempty,oneof=debug release"`
+	Slow    string        `yaml:"slow" validate:"omitempty,duration"`
+}
+
+func TestDecode(t *testing.T) {
+	conf := &testServerConfig{}
+	if err := Decode("server", map[string]interface{}{"port": "9000"}, conf, false); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Host != "127.0.0.1" || conf.Port != 9000 || conf.Timeout != 3*time.Second {
+		t.Fatalf("unexpected config: %+v", conf)
+	}
+
+	input := map[string]interface{}{"port": 70000, "mode": "test", "slow": "1x", "unknown": 1}
+	err := Decode("server", input, &testServerConfig{}, true)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("err = %v, want ValidationErrors", err)
+	}
+	keys := map[string]bool{}
+	for _, e := range errs {
+		keys[e.Key] = true
+	}
+	for _, key := range []string{"server.unknown", "server.port", "server.mode", "server.slow"} {
+		if !keys[key] {
+			t.Fatalf("missing error of %s in %v", key, errs)
+		}
+	}
+}
+
+type testFeatureConfig struct {
+	Enabled bool `yaml:"enabled" default:"true"`
+	Retry   int  `yaml:"retry" default:"3"`
+	Server  struct {
+		Port int `yaml:"port" default:"8080"`
+	} `yaml:"server"`
+}
+
+func TestDecode_ExplicitZero(t *testing.T) {
+	conf := &testFeatureConfig{}
+	input := map[string]interface{}{"enabled": false, "server": map[string]interface{}{"port": 0}}
+	if err := Decode("feature", input, conf, false); err != nil {
+		t.Fatal(err)
+	}
+	if conf.Enabled || conf.Server.Port != 0 {
+		t.Fatalf("explicit zero values should be kept: %+v", conf)
+	}
+	if conf.Retry != 3 {
+		t.Fatalf("retry = %d, want default 3", conf.Retry)
+	}
+
+	conf = &testFeatureConfig{}
+	if err := Decode("feature", nil, conf, false); err != nil {
+		t.Fatal(err)
+	}
+	if !conf.Enabled || conf.Server.Port != 8080 {
+		t.Fatalf("missing keys should use defaults: %+v", conf)
+	}
+}
+
+func TestRegisterSchema_Duplicate(t *testing.T) {
+	RegisterSchema("test.duplicate", (*testServerConfig)(nil))
+	defer func() {
+		if recover() == nil {
+			t.Fatal("RegisterSchema should panic on duplicate key")
+		}
+	}()
+	RegisterSchema("test.duplicate", (*testServerConfig)(nil))
+}
+
+func TestHadeConfig_Locate(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	writeFile(t, filepath.Join(folder, "app.yaml"), "name: hade\nserver:\n  host: 127.0.0.1\n  port: 70000\n")
+
+	ins, err := NewHadeConfig(framework.NewHadeContainer(), []string{folder}, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := ins.(*HadeConfig)
+
+	if err := conf.Load("app.server", &testServerConfig{}); err == nil {
+		t.Fatal("Load should validate the config")
+	}
+	file, line := conf.Locate("app.server.port")
+	if file != filepath.Join(folder, "app.yaml") || line != 4 {
+		t.Fatalf("Locate = %s:%d, want app.yaml:4", file, line)
+	}
+	if _, line := conf.Locate("app.server.missing"); line != 2 {
+		t.Fatalf("missing key should locate its parent, got line %d", line)
+	}
+}
@@ -0,0 +1,249 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+const (
+	// SecretKeyEnv 配置加密密钥的环境变量，格式为 id:base64密钥，多个密钥使用逗号分隔，第一个密钥用于加密
+	SecretKeyEnv = "HADE_CONFIG_KEY"
+	// SecretKeyFileEnv 配置加密密钥文件的环境变量，文件内容和 HADE_CONFIG_KEY 的格式相同，也可以每行一个密钥
+	SecretKeyFileEnv = "HADE_CONFIG_KEY_FILE"
+)
+
+// Keyring 加密配置使用的密钥，每个密钥有一个 id，加密的结果带有密钥的 id，更换密钥之后旧的配置依然可以解密
+type Keyring struct {
+	current string            // 加密使用的密钥 id
+	keys    map[string][]byte // 所有可以用于解密的密钥
+}
+
+// NewKeyring 从环境变量 HADE_CONFIG_KEY 或者 HADE_CONFIG_KEY_FILE 指定的文件中读取密钥
+// 密钥的格式为 id:base64密钥，比如 v2:xxx,v1:yyy，密钥长度为 16、24 或者 32 字节
+func NewKeyring(env contract.Env) (*Keyring, error) {
+	content := env.Get(SecretKeyEnv)
+	if content == "" && env.Get(SecretKeyFileEnv) != "" {
+		bf, err := ioutil.ReadFile(env.Get(SecretKeyFileEnv))
+		if err != nil {
+			return nil, errors.Wrap(err, "read config key file")
+		}
+		content = string(bf)
+	}
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("config key is not set, set %s or %s", SecretKeyEnv, SecretKeyFileEnv)
+	}
+
+	keyring := &Keyring{keys: map[string][]byte{}}
+	for _, item := range strings.FieldsFunc(content, func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	}) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		id, encoded := "", item
+		if n := strings.Index(item, ":"); n >= 0 {
+			id, encoded = item[:n], item[n+1:]
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("config key %s is not valid base64", strconv.Quote(id))
+		}
+		if _, err := aes.NewCipher(key); err != nil {
+			return nil, fmt.Errorf("config key %s: %v", strconv.Quote(id), err)
+		}
+		if len(keyring.keys) == 0 {
+			keyring.current = id
+		}
+		keyring.keys[id] = key
+	}
+	return keyring, nil
+}
+
+// Encrypt 使用当前密钥加密 plain，返回可以直接写在配置文件中的 enc(id:密文)
+func (k *Keyring) Encrypt(plain string) (string, error) {
+	gcm, err := newGCM(k.keys[k.current])
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+	if k.current != "" {
+		encoded = k.current + ":" + encoded
+	}
+	return "enc(" + encoded + ")", nil
+}
+
+// Decrypt 解密 enc(id:密文) 格式的配置，使用密文中 id 对应的密钥
+func (k *Keyring) Decrypt(val string) (string, error) {
+	encoded, ok := encryptedValue(val)
+	if !ok {
+		return "", errors.New("value is not in enc(...) format")
+	}
+	id := ""
+	if n := strings.Index(encoded, ":"); n >= 0 {
+		id, encoded = encoded[:n], encoded[n+1:]
+	}
+	key, ok := k.keys[id]
+	if !ok {
+		return "", fmt.Errorf("config key %s not found", strconv.Quote(id))
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.Wrap(err, "decode encrypted value")
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("encrypted value is too short")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("decrypt with config key %s failed", strconv.Quote(id))
+	}
+	return string(plain), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptedValue 判断 val 是否为 enc(...) 格式，返回括号中的内容
+func encryptedValue(val string) (string, bool) {
+	val = strings.TrimSpace(val)
+	if !strings.HasPrefix(val, "enc(") || !strings.HasSuffix(val, ")") {
+		return "", false
+	}
+	return val[len("enc(") : len(val)-1], true
+}
+
+// decryptValues 解密配置文件中所有 enc(...) 格式的值，解密的配置项记录在 secrets 中
+// 配置文件中没有加密的值的时候不需要密钥
+func (conf *HadeConfig) decryptValues(val interface{}, path string, secrets map[string]bool) (interface{}, error) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			decrypted, err := conf.decryptValues(child, path+"."+key, secrets)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = decrypted
+		}
+		return v, nil
+	case []interface{}:
+		for i, child := range v {
+			decrypted, err := conf.decryptValues(child, path+"."+strconv.Itoa(i), secrets)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = decrypted
+		}
+		return v, nil
+	case string:
+		if _, ok := encryptedValue(v); !ok {
+			return v, nil
+		}
+		keyring, err := conf.secretKeyring()
+		if err != nil {
+			return nil, errors.Wrap(err, path)
+		}
+		plain, err := keyring.Decrypt(v)
+		if err != nil {
+			return nil, errors.Wrap(err, path)
+		}
+		secrets[path] = true
+		// 解密的值中的 ${ 不作为引用解析
+		return strings.ReplaceAll(plain, "${", "$${"), nil
+	default:
+		return val, nil
+	}
+}
+
+// secretKeyring 第一次使用的时候通过 env 服务读取密钥
+func (conf *HadeConfig) secretKeyring() (*Keyring, error) {
+	if conf.keyring != nil {
+		return conf.keyring, nil
+	}
+	if !conf.container.IsBind(contract.EnvKey) {
+		return nil, errors.New("env service is not bind, can not read config key")
+	}
+	keyring, err := NewKeyring(conf.container.MustMake(contract.EnvKey).(contract.Env))
+	if err != nil {
+		return nil, err
+	}
+	conf.keyring = keyring
+	return keyring, nil
+}
+
+// IsSecret 判断 key 对应的配置项是否是从 enc(...) 解密得到的
+// key 下面的任意配置项（包括列表中的元素）是加密配置，或者 key 引用了加密配置的时候也返回 true
+func (conf *HadeConfig) IsSecret(key string) bool {
+	conf.lock.RLock()
+	defer conf.lock.RUnlock()
+	return hasSecret(conf.secrets, key)
+}
+
+// activeSecrets 返回合并之后生效的加密配置项，被其他来源覆盖的加密配置不算，调用方需要持有锁
+func (conf *HadeConfig) activeSecrets() map[string]bool {
+	ret := map[string]bool{}
+	for _, layer := range conf.layers {
+		for file, secrets := range layer.secrets {
+			source := layer.source.Path(file)
+			for path := range secrets {
+				if leaf, ok := conf.leafOf(path); ok && conf.sources[leaf] == source {
+					ret[path] = true
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// leafOf 返回 path 所在的叶子节点，列表中的元素 path.N 属于列表对应的叶子节点
+func (conf *HadeConfig) leafOf(path string) (string, bool) {
+	for p := path; ; {
+		if _, ok := conf.sources[p]; ok {
+			return p, true
+		}
+		i := strings.LastIndex(p, ".")
+		if i < 0 {
+			return "", false
+		}
+		p = p[:i]
+	}
+}
+
+// hasSecret 判断 key 或者 key 下面的配置项是否在 secrets 中
+func hasSecret(secrets map[string]bool, key string) bool {
+	if secrets[key] {
+		return true
+	}
+	prefix := key + "."
+	for path := range secrets {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
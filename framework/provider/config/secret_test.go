@@ -0,0 +1,154 @@
+package config
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+type testEnv map[string]string
+
+func (e testEnv) AppEnv() string          { return contract.EnvTesting }
+func (e testEnv) Get(key string) string   { return e[key] }
+func (e testEnv) IsExist(key string) bool { _, ok := e[key]; return ok }
+func (e testEnv) All() map[string]string  { return e }
+
+func (e testEnv) GetInt(key string, def int) int                          { return def }
+func (e testEnv) GetBool(key string, def bool) bool                       { return def }
+func (e testEnv) GetDuration(key string, def time.Duration) time.Duration { return def }
+func (e testEnv) GetStringSlice(key string, def []string) []string        { return def }
+
+type testEnvProvider struct {
+	env testEnv
+}
+
+func (p *testEnvProvider) Register(container framework.Container) framework.NewInstance {
+	return func(params ...interface{}) (interface{}, error) { return p.env, nil }
+}
+func (p *testEnvProvider) Boot(container framework.Container) error           { return nil }
+func (p *testEnvProvider) IsDefer() bool                                      { return false }
+func (p *testEnvProvider) Params(container framework.Container) []interface{} { return nil }
+func (p *testEnvProvider) Name() string                                       { return contract.EnvKey }
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old, err := NewKeyring(testEnv{SecretKeyEnv: "v1:" + testKey('a')})
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := old.Encrypt("root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(encrypted, "enc(v1:") {
+		t.Fatalf("encrypted = %s, want key id prefix", encrypted)
+	}
+
+	// 新的密钥放在第一个，旧的配置依然可以解密
+	keyring, err := NewKeyring(testEnv{SecretKeyEnv: "v2:" + testKey('b') + ",v1:" + testKey('a')})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := keyring.Decrypt(encrypted); err != nil || plain != "root" {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+	if encrypted, _ := keyring.Encrypt("root"); !strings.HasPrefix(encrypted, "enc(v2:") {
+		t.Fatalf("encrypted = %s, want current key v2", encrypted)
+	}
+	if _, err := old.Decrypt(strings.Replace(encrypted, "v1:", "v2:", 1)); err == nil {
+		t.Fatal("Decrypt with unknown key id should fail")
+	}
+}
+
+func TestHadeConfig_Secret(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	env := testEnv{SecretKeyEnv: "v1:" + testKey('a')}
+	keyring, _ := NewKeyring(env)
+	encrypted, _ := keyring.Encrypt("p@ss${word}")
+	writeFile(t, filepath.Join(folder, "database.yaml"), "default:\n  username: root\n  password: "+encrypted+"\n")
+
+	container := framework.NewHadeContainer()
+	if err := container.Bind(&testEnvProvider{env: env}); err != nil {
+		t.Fatal(err)
+	}
+	ins, err := NewHadeConfig(container, []string{folder}, map[string]string(env))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := ins.(*HadeConfig)
+	if got := conf.GetString("database.default.password"); got != "p@ss${word}" {
+		t.Fatalf("password = %q", got)
+	}
+	if !conf.IsSecret("database.default.password") || conf.IsSecret("database.default.username") {
+		t.Fatal("only password should be secret")
+	}
+
+	// 没有密钥的时候加载失败
+	if _, err := NewHadeConfig(framework.NewHadeContainer(), []string{folder}, map[string]string{}); err == nil {
+		t.Fatal("load encrypted config without key should fail")
+	}
+}
+
+func TestHadeConfig_SecretInListAndRef(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	env := testEnv{SecretKeyEnv: "v1:" + testKey('a')}
+	keyring, _ := NewKeyring(env)
+	encrypted, _ := keyring.Encrypt("secret")
+	writeFile(t, filepath.Join(folder, "app.yaml"), "tokens:\n  - public\n  - "+encrypted+"\n"+
+		"password: "+encrypted+"\n"+
+		"dsn: root:${app.password}@tcp(127.0.0.1)\n"+
+		"copy: ${app.dsn}\n"+
+		"name: hade\n"+
+		"title: ${app.name}\n")
+
+	container := framework.NewHadeContainer()
+	if err := container.Bind(&testEnvProvider{env: env}); err != nil {
+		t.Fatal(err)
+	}
+	ins, err := NewHadeConfig(container, []string{folder}, map[string]string(env))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := ins.(*HadeConfig)
+
+	// 列表中的加密元素
+	if !conf.IsSecret("app.tokens") || !conf.IsSecret("app.tokens.1") || conf.IsSecret("app.tokens.0") {
+		t.Fatal("only the encrypted element of app.tokens should be secret")
+	}
+	// 引用了加密配置的配置项
+	if got := conf.GetString("app.dsn"); got != "root:secret@tcp(127.0.0.1)" {
+		t.Fatalf("dsn = %q", got)
+	}
+	if !conf.IsSecret("app.dsn") || !conf.IsSecret("app.copy") {
+		t.Fatal("config referencing a secret should be secret")
+	}
+	if conf.IsSecret("app.title") {
+		t.Fatal("config referencing a plain config should not be secret")
+	}
+
+	// 被覆盖之后不再是加密配置
+	if err := conf.Set("app.password", "plain"); err != nil {
+		t.Fatal(err)
+	}
+	if conf.IsSecret("app.password") || conf.IsSecret("app.dsn") {
+		t.Fatal("overridden secret should not be secret")
+	}
+}
@@ -1,26 +1,16 @@
 package config
 
 import (
-	"bytes"
-	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/yefangyong/go-frame/framework/contract"
 
-	"github.com/mitchellh/mapstructure"
-
 	"github.com/spf13/cast"
 
-	"github.com/fsnotify/fsnotify"
-
-	"github.com/ghodss/yaml"
-
 	"github.com/pkg/errors"
 
 	"github.com/yefangyong/go-frame/framework"
@@ -28,12 +18,20 @@ import (
 
 type HadeConfig struct {
 	container framework.Container    // 容器
-	folder    string                 // 文件夹
+	layers    []*configLayer         // 配置来源，按照加载顺序排列，后面的来源覆盖前面的来源
 	keyDelim  string                 // 路径的分隔符，默认为点
 	lock      sync.RWMutex           // 配置文件的读写锁
 	envMaps   map[string]string      // 所有的环境变量
-	confMaps  map[string]interface{} // 配置文件结构，以 key 为文件名
-	confRaws  map[string][]byte      // 配置文件的原始信息
+	rawMaps   map[string]interface{} // 合并之后没有解析引用的配置文件结构，以 key 为文件名
+	confMaps  map[string]interface{} // 解析引用之后的配置文件结构，以 key 为文件名
+	sources   map[string]string      // 每个配置项（叶子节点）的来源文件
+	keyring   *Keyring               // 解密配置使用的密钥，第一次遇到加密的配置时读取
+	secrets   map[string]bool        // 生效的加密配置项，包括列表中的元素和引用了加密配置的配置项
+	overrides []configOverride       // 覆盖配置文件的配置项，按照优先级从低到高排列
+
+	watchers    []*configWatcher // 配置变化的订阅者
+	debounce    time.Duration    // 配置变化之后通知订阅者的延迟
+	notifyTimer *time.Timer
 }
 
 // 查找某个路径的配置项
@@ -117,26 +115,20 @@ func (conf *HadeConfig) GetStringMapStringSlice(key string) map[string][]string
 	return cast.ToStringMapStringSlice(conf.find(key))
 }
 
+// Load 将 key 对应的配置解析到 val 中，支持 default 标签设置默认值和 validate 标签检查配置
 func (conf *HadeConfig) Load(key string, val interface{}) error {
-	//return mapstructure.Decode(conf.find(key), val)
-	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		TagName: "yaml",
-		Result:  val,
-	})
-	if err != nil {
-		return err
-	}
-	return decoder.Decode(conf.find(key))
+	return Decode(key, conf.find(key), val, false)
 }
 
+// NewHadeConfig 按照顺序加载配置目录和其他配置来源，后面的配置文件按照 key 深度合并到前面的配置上
+// 不存在的目录会被忽略，但是至少需要有一个目录或者配置来源
 func NewHadeConfig(params ...interface{}) (interface{}, error) {
 	container := params[0].(framework.Container)
-	envFolder := params[1].(string)
+	folders := params[1].([]string)
 	envMaps := params[2].(map[string]string)
-
-	// 检查文件夹是否存在
-	if _, err := os.Stat(envFolder); os.IsNotExist(err) {
-		return nil, errors.New("folder " + envFolder + " not exist: " + err.Error())
+	var extras []Source
+	if len(params) > 3 {
+		extras = params[3].([]Source)
 	}
 
 	// 实例化
@@ -145,134 +137,154 @@ func NewHadeConfig(params ...interface{}) (interface{}, error) {
 		envMaps:   envMaps,
 		lock:      sync.RWMutex{},
 		keyDelim:  ".",
-		folder:    envFolder,
+		rawMaps:   map[string]interface{}{},
 		confMaps:  map[string]interface{}{},
-		confRaws:  map[string][]byte{},
+		sources:   map[string]string{},
+		debounce:  defaultDebounce,
+		// 命令行中的 --set 在创建配置服务的时候就生效，其他服务初始化的时候读取的已经是覆盖之后的配置
+		overrides: append(envOverrides(envMaps), setArgs(os.Args[1:])...),
 	}
 
-	// 读取每一个文件
-	files, err := ioutil.ReadDir(envFolder)
-	if err != nil {
-		return nil, errors.WithStack(err)
+	var sources []Source
+	for _, folder := range folders {
+		// 检查文件夹是否存在
+		if _, err := os.Stat(folder); os.IsNotExist(err) {
+			continue
+		}
+		source, err := NewFolderSource(folder)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	sources = append(sources, extras...)
+	if len(sources) == 0 {
+		return nil, errors.New("config folder " + strings.Join(folders, ",") + " not exist")
 	}
 
-	for _, file := range files {
-		fileName := file.Name()
-		err := hadeConf.loadConfigFile(envFolder, fileName)
+	for _, source := range sources {
+		layer := &configLayer{
+			source:  source,
+			maps:    map[string]map[string]interface{}{},
+			raws:    map[string][]byte{},
+			secrets: map[string]map[string]bool{},
+		}
+		hadeConf.layers = append(hadeConf.layers, layer)
+
+		// 读取每一个文件
+		files, err := source.Load()
 		if err != nil {
-			log.Println(err)
-			continue
+			return nil, err
+		}
+		for file, content := range files {
+			if err := hadeConf.readConfigFile(layer, file, content); err != nil {
+				return nil, err
+			}
 		}
 	}
-
-	// 监控文件夹文件，配置文件热更新
-	watch, err := fsnotify.NewWatcher()
-	if err != nil {
-		return nil, err
+	// 没有配置文件的配置也可以使用环境变量设置
+	for _, o := range hadeConf.overrides {
+		hadeConf.merge(strings.Split(o.key, hadeConf.keyDelim)[0])
 	}
-	err = watch.Add(envFolder)
-	if err != nil {
+	// 所有文件都读取之后再解析引用，引用的配置可以在任意文件中
+	if err := hadeConf.refresh(); err != nil {
 		return nil, err
 	}
-	go func() {
-		defer func() {
-			if err := recover(); err != nil {
-				fmt.Println(err)
-			}
-		}()
-		for {
-			select {
-			case ev := <-watch.Events:
-				{
-					path, _ := filepath.Abs(ev.Name)
-					index := strings.LastIndex(path, string(os.PathSeparator))
-					folder := path[:index]
-					fileName := path[index+1:]
-					if ev.Op&fsnotify.Create == fsnotify.Create {
-						log.Println("创建文件：", ev.Name)
-						hadeConf.loadConfigFile(folder, fileName)
-					}
-					if ev.Op&fsnotify.Write == fsnotify.Write {
-						log.Println("写入文件：", ev.Name)
-						hadeConf.loadConfigFile(folder, fileName)
-					}
-					if ev.Op&fsnotify.Remove == fsnotify.Remove {
-						log.Println("删除文件：", ev.Name)
-						hadeConf.removeConfigFile(folder, fileName)
-					}
-				}
-			case err := <-watch.Errors:
-				{
-					log.Println("error: ", err)
-					return
-				}
+
+	// 监听配置来源的变化，配置文件热更新
+	for _, layer := range hadeConf.layers {
+		layer := layer
+		err := layer.source.Watch(func(files map[string][]byte) {
+			if err := hadeConf.updateConfigFiles(layer, files); err != nil {
+				log.Println(err)
 			}
+		})
+		if err != nil {
+			return nil, err
 		}
-	}()
+	}
 	return hadeConf, nil
 }
 
-// 删除某个配置文件
-func (conf *HadeConfig) removeConfigFile(folder string, file string) error {
+// updateConfigFiles 更新配置来源中有变化的配置文件，内容为 nil 的文件被删除，所有文件更新之后再解析引用
+// 解析失败的文件保留原来的配置
+func (conf *HadeConfig) updateConfigFiles(layer *configLayer, files map[string][]byte) error {
 	conf.lock.Lock()
 	defer conf.lock.Unlock()
-	s := strings.Split(file, ".")
-	if len(s) == 2 && (s[1] == "yaml" || s[1] == "yml") {
-		name := s[0]
-		// 删除内存中对应的key
-		delete(conf.confMaps, name)
-		delete(conf.confRaws, name)
+	var errs []string
+	for file, content := range files {
+		if findParser(file) == nil {
+			continue
+		}
+		if content != nil {
+			if err := conf.readConfigFile(layer, file, content); err != nil {
+				errs = append(errs, err.Error())
+			}
+			continue
+		}
+		// 删除内存中对应的文件，并使用其他的同名文件重新合并
+		delete(layer.maps, file)
+		delete(layer.raws, file)
+		delete(layer.secrets, file)
+		conf.merge(configName(file))
+	}
+	if err := conf.refresh(); err != nil {
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
 	}
 	return nil
 }
 
-// 读取某个配置文件
-func (conf *HadeConfig) loadConfigFile(folder string, file string) error {
-	conf.lock.Lock()
-	defer conf.lock.Unlock()
-
-	// 判断文件是否以为yaml或者yml作为后缀
-	s := strings.Split(file, ".")
-	if len(s) == 2 && (s[1] == "yaml" || s[1] == "yml") {
-		name := s[0]
-
-		// 读取文件的内容
-		bf, err := ioutil.ReadFile(filepath.Join(folder, file))
-		if err != nil {
-			return err
-		}
-
-		// 直接针对文本做环境变量的替换
-		bf = replace(bf, conf.envMaps)
+// 解析某个配置文件并且合并，文件的格式由扩展名决定，比如 database.yaml、database.json、app.local.toml
+// 配置文件中的 env(KEY) 在这里替换，enc(...) 在这里解密，调用方需要持有写锁
+func (conf *HadeConfig) readConfigFile(layer *configLayer, file string, bf []byte) error {
+	// 判断文件是否为支持的格式
+	parser := findParser(file)
+	if parser == nil {
+		return nil
+	}
+	name := configName(file)
+	path := layer.source.Path(file)
 
-		// 解析对应的文件
-		c := map[string]interface{}{}
-		if err := yaml.Unmarshal(bf, &c); err != nil {
-			return err
-		}
-		conf.confRaws[name] = bf
-		conf.confMaps[name] = c
-
-		// 读取app.path中的信息，更新app对应的folder
-		if name == "app" && conf.container.IsBind(contract.AppKey) {
-			if p, ok := c["path"]; ok {
-				appService := conf.container.MustMake(contract.AppKey).(contract.App)
-				appService.LoadAppConfig(cast.ToStringMapString(p))
-			}
-		}
+	// 解析对应的文件，然后在解析的结果中替换环境变量，环境变量中的引号、换行等不会影响文件的解析
+	c, err := parser(bf)
+	if err != nil {
+		return errors.Wrap(err, "parse config file "+path)
+	}
+	if _, err := expandEnv(c, name, conf.envMaps); err != nil {
+		return errors.Wrap(err, "config file "+path)
 	}
+	secrets := map[string]bool{}
+	if _, err := conf.decryptValues(c, name, secrets); err != nil {
+		return errors.Wrap(err, "config file "+path)
+	}
+	layer.raws[file] = bf
+	layer.secrets[file] = secrets
+	layer.maps[file] = c
+	conf.merge(name)
 	return nil
 }
 
-func replace(content []byte, maps map[string]string) []byte {
-	if maps == nil {
-		return content
+// refresh 解析合并之后配置中的 ${key} 引用，并且通知配置的变化，调用方需要持有写锁
+// 解析失败的时候保留原来的配置
+func (conf *HadeConfig) refresh() error {
+	secrets := conf.activeSecrets()
+	confMaps, err := resolveRefs(conf.rawMaps, secrets)
+	if err != nil {
+		return err
 	}
-
-	// 直接使用ReplaceAll替换，性能可能不是最优，但是配置文件加载，频率很低，问题不大
-	for key, val := range maps {
-		reKey := "env(" + key + ")"
-		content = bytes.ReplaceAll(content, []byte(reKey), []byte(val))
+	conf.confMaps = confMaps
+	conf.secrets = secrets
+	conf.scheduleNotify()
+
+	// 读取app.path中的信息，更新app对应的folder
+	if conf.container.IsBind(contract.AppKey) {
+		if p, ok := cast.ToStringMap(conf.confMaps["app"])["path"]; ok {
+			appService := conf.container.MustMake(contract.AppKey).(contract.App)
+			appService.LoadAppConfig(cast.ToStringMapString(p))
+		}
 	}
-	return content
+	return nil
 }
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yefangyong/go-frame/framework"
+)
+
+func writeFile(t *testing.T, file string, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHadeConfig_Layers(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	base, env := filepath.Join(folder, "base"), filepath.Join(folder, "testing")
+	_ = os.Mkdir(base, 0755)
+	_ = os.Mkdir(env, 0755)
+	writeFile(t, filepath.Join(base, "database.yaml"), "timeout: 5s\ndefault:\n  host: 127.0.0.1\n  port: 3306\n  tags: [a, b]\n")
+	writeFile(t, filepath.Join(base, "cache.yaml"), "driver: memory\n")
+	writeFile(t, filepath.Join(env, "database.yaml"), "default:\n  host: db.testing\n  tags: [c]\n")
+
+	ins, err := NewHadeConfig(framework.NewHadeContainer(), []string{base, env, filepath.Join(folder, "missing")}, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := ins.(*HadeConfig)
+
+	if got := conf.GetString("database.default.host"); got != "db.testing" {
+		t.Fatalf("host = %s, want overlay value", got)
+	}
+	if got := conf.GetInt("database.default.port"); got != 3306 {
+		t.Fatalf("port = %d, want base value", got)
+	}
+	if got := conf.GetStringSlice("database.default.tags"); len(got) != 1 || got[0] != "c" {
+		t.Fatalf("tags = %v, lists should be replaced", got)
+	}
+	if got := conf.GetString("cache.driver"); got != "memory" {
+		t.Fatalf("cache.driver = %s", got)
+	}
+
+	sources := conf.Sources("database.default")
+	if sources["database.default.host"] != filepath.Join(env, "database.yaml") ||
+		sources["database.default.port"] != filepath.Join(base, "database.yaml") {
+		t.Fatalf("unexpected sources: %v", sources)
+	}
+
+	// 删除环境目录中的文件之后恢复为 base 中的配置
+	if err := os.Remove(filepath.Join(env, "database.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(3 * time.Second)
+	for conf.GetString("database.default.host") != "127.0.0.1" {
+		if time.Now().After(deadline) {
+			t.Fatal("config not reloaded after overlay removed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if conf.Sources("database.default.host")["database.default.host"] != filepath.Join(base, "database.yaml") {
+		t.Fatal("source should fall back to base file")
+	}
+}
+
+func TestHadeConfig_Formats(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	writeFile(t, filepath.Join(folder, "app.yaml"), "name: hade\nport: 80\n")
+	writeFile(t, filepath.Join(folder, "app.local.json"), `{"port": 8080, "owner": "env(USER)"}`)
+	writeFile(t, filepath.Join(folder, "cache.toml"), "driver = \"redis\"\n[redis]\nhost = \"127.0.0.1\"\nport = 6379\n")
+	writeFile(t, filepath.Join(folder, "legacy.properties"), "db.default.host=10.0.0.1\ndb.default.port=3306\n")
+	writeFile(t, filepath.Join(folder, "README.md"), "not a config file")
+
+	ins, err := NewHadeConfig(framework.NewHadeContainer(), []string{folder}, map[string]string{"USER": "ops"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := ins.(*HadeConfig)
+
+	cases := map[string]string{
+		"app.name":               "hade",
+		"app.port":               "8080",
+		"app.owner":              "ops",
+		"cache.driver":           "redis",
+		"cache.redis.port":       "6379",
+		"legacy.db.default.host": "10.0.0.1",
+		"legacy.db.default.port": "3306",
+	}
+	for key, want := range cases {
+		if got := conf.GetString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if conf.IsExist("README") {
+		t.Error("unsupported file should be ignored")
+	}
+	if got := conf.Sources("app.port")["app.port"]; got != filepath.Join(folder, "app.local.json") {
+		t.Errorf("app.port source = %s", got)
+	}
+}
+
+func TestHadeConfig_Watch(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	file := filepath.Join(folder, "log.yaml")
+	writeFile(t, file, "level: info\ndriver: console\n")
+
+	ins, err := NewHadeConfig(framework.NewHadeContainer(), []string{folder}, map[string]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := ins.(*HadeConfig)
+
+	changes := make(chan [2]interface{}, 10)
+	conf.Watch("log.level", func(oldVal, newVal interface{}) {
+		changes <- [2]interface{}{oldVal, newVal}
+	})
+	cancel := conf.Watch("log.driver", func(oldVal, newVal interface{}) {
+		t.Error("log.driver did not change")
+	})
+	defer cancel()
+
+	// 连续的多次写入只通知一次
+	writeFile(t, file, "level: debug\ndriver: console\n")
+	writeFile(t, file, "level: trace\ndriver: console\n")
+	select {
+	case change := <-changes:
+		if change[0] != "info" || change[1] != "trace" {
+			t.Fatalf("change = %v, want info -> trace", change)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("watcher not notified")
+	}
+	select {
+	case change := <-changes:
+		t.Fatalf("unexpected second notification %v", change)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
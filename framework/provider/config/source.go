@@ -0,0 +1,132 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// Source 配置文件的来源，比如本地目录或者远程的配置中心
+// 每个来源中的配置文件以文件名区分，比如 database.yaml，文件的格式由扩展名决定
+type Source interface {
+	// Path 返回文件在来源中的完整路径，用于记录配置项的来源
+	Path(file string) string
+	// Load 读取来源中所有的配置文件，以文件名为 key
+	Load() (map[string][]byte, error)
+	// Watch 在后台监听来源的变化，有变化的时候调用 onChange，删除的文件内容为 nil
+	Watch(onChange func(files map[string][]byte)) error
+	// Close 停止 Watch 启动的后台监听
+	Close() error
+}
+
+// FolderSource 本地目录中的配置文件，使用 fsnotify 监听文件的变化
+type FolderSource struct {
+	folder string
+
+	lock  sync.Mutex
+	watch *fsnotify.Watcher // Watch 创建的监听，Close 的时候关闭
+}
+
+// NewFolderSource 创建本地目录的配置来源，folder 会转换为绝对路径
+func NewFolderSource(folder string) (*FolderSource, error) {
+	folder, err := filepath.Abs(folder)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &FolderSource{folder: folder}, nil
+}
+
+func (s *FolderSource) Path(file string) string {
+	return filepath.Join(s.folder, file)
+}
+
+func (s *FolderSource) Load() (map[string][]byte, error) {
+	files, err := ioutil.ReadDir(s.folder)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	ret := map[string][]byte{}
+	for _, file := range files {
+		if file.IsDir() || findParser(file.Name()) == nil {
+			continue
+		}
+		bf, err := ioutil.ReadFile(s.Path(file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		ret[file.Name()] = bf
+	}
+	return ret, nil
+}
+
+func (s *FolderSource) Watch(onChange func(files map[string][]byte)) error {
+	watch, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watch.Add(s.folder); err != nil {
+		watch.Close()
+		return err
+	}
+	s.lock.Lock()
+	s.watch = watch
+	s.lock.Unlock()
+	go func() {
+		defer func() {
+			if err := recover(); err != nil {
+				fmt.Println(err)
+			}
+		}()
+		for {
+			select {
+			case ev, ok := <-watch.Events:
+				if !ok {
+					return
+				}
+				file := filepath.Base(ev.Name)
+				if findParser(file) == nil {
+					continue
+				}
+				if ev.Op&(fsnotify.Create|fsnotify.Write) != 0 {
+					log.Println("更新文件：", ev.Name)
+					bf, err := ioutil.ReadFile(ev.Name)
+					if err != nil {
+						// 写入之后马上被删除的文件，等待删除事件
+						if !os.IsNotExist(err) {
+							log.Println(err)
+						}
+						continue
+					}
+					onChange(map[string][]byte{file: bf})
+				}
+				if ev.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+					log.Println("删除文件：", ev.Name)
+					onChange(map[string][]byte{file: nil})
+				}
+			case err, ok := <-watch.Errors:
+				if ok {
+					log.Println("error: ", err)
+				}
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *FolderSource) Close() error {
+	s.lock.Lock()
+	watch := s.watch
+	s.watch = nil
+	s.lock.Unlock()
+	if watch == nil {
+		return nil
+	}
+	return watch.Close()
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+// defaultDebounce 配置文件变化之后等待的时间，编辑器保存文件的时候可能会产生多个事件，只通知一次
+const defaultDebounce = 100 * time.Millisecond
+
+// configWatcher 一个配置变化的订阅
+type configWatcher struct {
+	key  string
+	fn   contract.ConfigWatcher
+	last interface{} // 上一次通知时 key 对应的值
+}
+
+func (conf *HadeConfig) Watch(key string, fn contract.ConfigWatcher) func() {
+	conf.lock.Lock()
+	defer conf.lock.Unlock()
+	w := &configWatcher{key: key, fn: fn, last: searchMap(conf.confMaps, strings.Split(key, conf.keyDelim))}
+	conf.watchers = append(conf.watchers, w)
+	return func() {
+		conf.lock.Lock()
+		defer conf.lock.Unlock()
+		for i, exist := range conf.watchers {
+			if exist == w {
+				conf.watchers = append(conf.watchers[:i:i], conf.watchers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// scheduleNotify 在配置变化之后延迟通知订阅者，延迟时间内的多次变化只通知一次，调用方需要持有写锁
+func (conf *HadeConfig) scheduleNotify() {
+	if len(conf.watchers) == 0 {
+		return
+	}
+	if conf.notifyTimer == nil {
+		conf.notifyTimer = time.AfterFunc(conf.debounce, conf.notify)
+		return
+	}
+	conf.notifyTimer.Reset(conf.debounce)
+}
+
+// notify 通知值有变化的订阅者，回调的时候不持有锁，回调中可以继续读取配置
+func (conf *HadeConfig) notify() {
+	type change struct {
+		fn             contract.ConfigWatcher
+		oldVal, newVal interface{}
+	}
+	var changes []change
+	conf.lock.Lock()
+	for _, w := range conf.watchers {
+		cur := searchMap(conf.confMaps, strings.Split(w.key, conf.keyDelim))
+		if reflect.DeepEqual(cur, w.last) {
+			continue
+		}
+		changes = append(changes, change{fn: w.fn, oldVal: w.last, newVal: cur})
+		w.last = cur
+	}
+	conf.lock.Unlock()
+
+	for _, c := range changes {
+		c.fn(c.oldVal, c.newVal)
+	}
+}
+
+// Shutdown 停止监听所有配置来源的变化，比如本地目录的 fsnotify 和远程配置的长轮询
+func (conf *HadeConfig) Shutdown(ctx context.Context) error {
+	conf.lock.Lock()
+	if conf.notifyTimer != nil {
+		conf.notifyTimer.Stop()
+	}
+	layers := conf.layers
+	conf.lock.Unlock()
+
+	var errs []string
+	for _, layer := range layers {
+		if err := layer.source.Close(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// yamlLine YAML 文件中的一行
+type yamlLine struct {
+	skip   bool   // 空行、注释或者文档分隔符
+	indent int    // 缩进的空格数
+	item   bool   // 是否为列表项
+	key    string // 配置项的 key，没有 key 的时候为空
+	head   string // 包括缩进和冒号的 key 部分，比如 "  port:"
+	value  string // 冒号之后的值，不包括注释
+	remark string // 行尾的注释，包括 #
+}
+
+func parseYAMLLine(line string) yamlLine {
+	trimmed := strings.TrimLeft(line, " ")
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+		return yamlLine{skip: true}
+	}
+	l := yamlLine{indent: len(line) - len(trimmed), item: strings.HasPrefix(trimmed, "- ") || trimmed == "-"}
+	for i := 0; i < len(trimmed); i++ {
+		if trimmed[i] == ':' && (i == len(trimmed)-1 || trimmed[i+1] == ' ') {
+			l.key = strings.Trim(strings.TrimSpace(trimmed[:i]), `"'`)
+			l.head = line[:l.indent+i+1]
+			rest := trimmed[i+1:]
+			if n := commentIndex(rest); n >= 0 {
+				l.value, l.remark = strings.TrimSpace(rest[:n]), rest[n:]
+			} else {
+				l.value = strings.TrimSpace(rest)
+			}
+			break
+		}
+	}
+	return l
+}
+
+// commentIndex 返回值中行尾注释开始的位置，引号中的 # 不是注释，没有注释的时候返回 -1
+func commentIndex(s string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || s[i-1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
+// yamlScalar 将值转换为 YAML 中的标量，包含特殊字符的时候使用双引号
+func yamlScalar(value string) string {
+	if value == "" || strings.TrimSpace(value) != value ||
+		strings.ContainsAny(value, ":#{}[],&*!|>'\"%@`\n\t\\") || strings.HasPrefix(value, "-") || strings.HasPrefix(value, "?") {
+		bf, _ := json.Marshal(value)
+		return string(bf)
+	}
+	return value
+}
+
+// SetYAMLValue 修改 YAML 文件内容中 path 对应的值，保留文件中的注释和格式，path 不存在的时候插入新的配置项
+// 只支持块格式的 map，不支持修改列表中的配置项
+func SetYAMLValue(content []byte, path []string, value string) ([]byte, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty config path")
+	}
+	lines := strings.Split(string(content), "\n")
+	parentIndent, start, end := -1, 0, len(lines)
+	for depth, seg := range path {
+		found, childIndent, last := -1, -1, start-1
+		for i := start; i < end; i++ {
+			l := parseYAMLLine(lines[i])
+			if l.skip {
+				continue
+			}
+			last = i
+			if childIndent < 0 {
+				childIndent = l.indent
+			}
+			if l.indent != childIndent {
+				continue
+			}
+			if l.item {
+				return nil, fmt.Errorf("%s is a list, can not set its items", strings.Join(path[:depth], "."))
+			}
+			if l.key == seg && found < 0 {
+				found = i
+			}
+		}
+
+		// 不存在的时候在上级配置项的最后插入
+		if found < 0 {
+			indent := childIndent
+			if indent < 0 {
+				indent = parentIndent + 2
+				if parentIndent < 0 {
+					indent = 0
+				}
+			}
+			var inserted []string
+			for j, s := range path[depth:] {
+				line := strings.Repeat(" ", indent+2*j) + yamlScalar(s) + ":"
+				if depth+j == len(path)-1 {
+					line += " " + yamlScalar(value)
+				}
+				inserted = append(inserted, line)
+			}
+			pos := last + 1
+			lines = append(lines[:pos], append(inserted, lines[pos:]...)...)
+			return []byte(strings.Join(lines, "\n")), nil
+		}
+
+		l := parseYAMLLine(lines[found])
+		blockEnd := end
+		for i := found + 1; i < end; i++ {
+			if next := parseYAMLLine(lines[i]); !next.skip && next.indent <= l.indent {
+				blockEnd = i
+				break
+			}
+		}
+		if depth == len(path)-1 {
+			for i := found + 1; i < blockEnd; i++ {
+				if !parseYAMLLine(lines[i]).skip {
+					return nil, fmt.Errorf("%s is a map, set its children instead", strings.Join(path, "."))
+				}
+			}
+			line := l.head + " " + yamlScalar(value)
+			if l.remark != "" {
+				line += " " + l.remark
+			}
+			lines[found] = line
+			return []byte(strings.Join(lines, "\n")), nil
+		}
+		if l.value != "" {
+			return nil, fmt.Errorf("%s is not a map", strings.Join(path[:depth+1], "."))
+		}
+		parentIndent, start, end = l.indent, found+1, blockEnd
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSetYAMLValue(t *testing.T) {
+	content := "# 数据库配置\ndefault:\n  host: 127.0.0.1 # 地址\n  port: 3306\n\nread:\n  tags: [a]\n"
+
+	out, err := SetYAMLValue([]byte(content), []string{"default", "host"}, "db.local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "  host: db.local # 地址\n") || !strings.HasPrefix(string(out), "# 数据库配置\n") {
+		t.Fatalf("comments should be kept:\n%s", out)
+	}
+
+	out, err = SetYAMLValue(out, []string{"default", "pool", "max_open"}, "10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "  port: 3306\n  pool:\n    max_open: 10\n") {
+		t.Fatalf("missing key should be inserted in its parent:\n%s", out)
+	}
+
+	out, err = SetYAMLValue(out, []string{"password"}, "p: #1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := parseYAML(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c["password"] != "p: #1" {
+		t.Fatalf("password = %v", c["password"])
+	}
+
+	if _, err := SetYAMLValue(out, []string{"default"}, "x"); err == nil {
+		t.Fatal("set a map should fail")
+	}
+	if _, err := SetYAMLValue(out, []string{"default", "host", "name"}, "x"); err == nil {
+		t.Fatal("set under a scalar should fail")
+	}
+}
@@ -27,3 +27,8 @@ func (d *DistributedProvider) Params(container framework.Container) []interface{
 func (d *DistributedProvider) Name() string {
 	return contract.DistributedKey
 }
+
+// Depends 本地分布式选择器需要通过 app 服务获取运行时目录
+func (d *DistributedProvider) Depends() []string {
+	return []string{contract.AppKey}
+}
@@ -0,0 +1,140 @@
+package env
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+// CheckError 声明的环境变量没有设置或者格式错误，包含所有的问题
+type CheckError struct {
+	Issues []contract.EnvIssue
+}
+
+func (e *CheckError) Error() string {
+	lines := []string{"env check failed:"}
+	for _, issue := range e.Issues {
+		line := "  " + issue.Var.Name + ": " + issue.Problem
+		if issue.Var.Description != "" {
+			line += " (" + issue.Var.Description + ")"
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseValue 按照类型解析环境变量的值，typ 为 contract.EnvTypeInt 等，空的时候为字符串
+func ParseValue(typ string, val string) (interface{}, error) {
+	switch typ {
+	case "", contract.EnvTypeString:
+		return val, nil
+	case contract.EnvTypeInt:
+		return strconv.Atoi(strings.TrimSpace(val))
+	case contract.EnvTypeBool:
+		return strconv.ParseBool(strings.TrimSpace(val))
+	case contract.EnvTypeDuration:
+		return time.ParseDuration(strings.TrimSpace(val))
+	case contract.EnvTypeList:
+		var items []string
+		for _, item := range strings.Split(val, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unknown env type %s", typ)
+	}
+}
+
+// CheckVars 检查 vars 中必须设置的变量是否设置，以及设置的变量格式是否正确
+func CheckVars(env contract.Env, vars []contract.EnvVar) []contract.EnvIssue {
+	var issues []contract.EnvIssue
+	for _, v := range vars {
+		if !env.IsExist(v.Name) || env.Get(v.Name) == "" {
+			if v.Required {
+				issues = append(issues, contract.EnvIssue{Var: v, Problem: "not set"})
+			}
+			continue
+		}
+		if _, err := ParseValue(v.Type, env.Get(v.Name)); err != nil {
+			issues = append(issues, contract.EnvIssue{Var: v, Problem: fmt.Sprintf("invalid %s %q", v.Type, env.Get(v.Name))})
+		}
+	}
+	return issues
+}
+
+// CheckEnv 检查 env 服务中应用声明的环境变量，有问题的时候返回包含所有问题的 *CheckError
+func CheckEnv(envService contract.Env) error {
+	checker, ok := envService.(contract.EnvChecker)
+	if !ok {
+		return nil
+	}
+	if issues := checker.Check(); len(issues) > 0 {
+		return &CheckError{Issues: issues}
+	}
+	return nil
+}
+
+// IsSecretKey 判断环境变量名是否像密码、密钥等敏感信息，按照 _ 分隔的完整单词匹配
+// 包含 PASSWORD、SECRET、TOKEN 等单词，或者以 KEY、PWD 单词结尾的变量名是敏感的，比如 DB_PASSWORD、API_KEY、MYSQL_PWD
+// PWD、KEYBOARD、MONKEY、SSH_KEY_PATH 这样的变量名不是
+func IsSecretKey(key string) bool {
+	words := strings.Split(strings.ToUpper(key), "_")
+	for _, word := range words {
+		switch word {
+		case "PASSWORD", "PASSWD", "SECRET", "TOKEN", "CREDENTIAL", "CREDENTIALS", "APIKEY":
+			return true
+		}
+	}
+	switch last := words[len(words)-1]; {
+	case last == "KEY":
+		return true
+	case last == "PWD":
+		// 只有 PWD 的时候是当前目录
+		return len(words) > 1
+	}
+	return false
+}
+
+func (h *HadeEnv) GetInt(key string, def int) int {
+	if val, err := ParseValue(contract.EnvTypeInt, h.Get(key)); err == nil {
+		return val.(int)
+	}
+	return def
+}
+
+func (h *HadeEnv) GetBool(key string, def bool) bool {
+	if val, err := ParseValue(contract.EnvTypeBool, h.Get(key)); err == nil {
+		return val.(bool)
+	}
+	return def
+}
+
+func (h *HadeEnv) GetDuration(key string, def time.Duration) time.Duration {
+	if val, err := ParseValue(contract.EnvTypeDuration, h.Get(key)); err == nil {
+		return val.(time.Duration)
+	}
+	return def
+}
+
+func (h *HadeEnv) GetStringSlice(key string, def []string) []string {
+	if !h.IsExist(key) {
+		return def
+	}
+	val, _ := ParseValue(contract.EnvTypeList, h.Get(key))
+	return val.([]string)
+}
+
+// Vars 返回应用声明的环境变量
+func (h *HadeEnv) Vars() []contract.EnvVar {
+	return h.vars
+}
+
+// Check 返回声明的环境变量中没有设置或者格式错误的变量
+func (h *HadeEnv) Check() []contract.EnvIssue {
+	return CheckVars(h, h.vars)
+}
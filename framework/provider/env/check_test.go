@@ -0,0 +1,91 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+func TestHadeEnv_Typed(t *testing.T) {
+	env := &HadeEnv{maps: map[string]string{"PORT": "8080", "DEBUG": "true", "TIMEOUT": "3s", "HOSTS": "a, b,,c", "BAD": "x"}}
+	if got := env.GetInt("PORT", 1); got != 8080 {
+		t.Fatalf("PORT = %d", got)
+	}
+	if got := env.GetInt("BAD", 1); got != 1 {
+		t.Fatalf("invalid int should return default, got %d", got)
+	}
+	if !env.GetBool("DEBUG", false) || env.GetBool("MISSING", false) {
+		t.Fatal("unexpected bool value")
+	}
+	if got := env.GetDuration("TIMEOUT", time.Second); got != 3*time.Second {
+		t.Fatalf("TIMEOUT = %v", got)
+	}
+	if got := env.GetStringSlice("HOSTS", nil); strings.Join(got, "|") != "a|b|c" {
+		t.Fatalf("HOSTS = %v", got)
+	}
+	if got := env.GetStringSlice("MISSING", []string{"d"}); len(got) != 1 || got[0] != "d" {
+		t.Fatalf("MISSING = %v", got)
+	}
+}
+
+func TestNewHadeEnv_Check(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	if err := ioutil.WriteFile(filepath.Join(folder, ".env"), []byte("PORT=abc\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	vars := []contract.EnvVar{
+		{Name: "DB_PASSWORD", Description: "数据库密码", Required: true},
+		{Name: "PORT", Type: contract.EnvTypeInt},
+		{Name: "OPTIONAL", Type: contract.EnvTypeDuration},
+	}
+	// 有问题的环境变量不影响 env 服务的创建
+	ins, err := NewHadeEnv(folder, vars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issues := ins.(*HadeEnv).Check(); len(issues) != 2 {
+		t.Fatalf("issues = %v", issues)
+	}
+	// env 服务提供者在创建的时候一次报告所有的问题
+	provider := &HadeEnvProvider{Vars: vars}
+	_, err = provider.Register(nil)(folder, vars)
+	checkErr, ok := err.(*CheckError)
+	if !ok {
+		t.Fatalf("err = %v, want *CheckError", err)
+	}
+	if len(checkErr.Issues) != 2 || !strings.Contains(err.Error(), "DB_PASSWORD: not set (数据库密码)") ||
+		!strings.Contains(err.Error(), `PORT: invalid int "abc"`) {
+		t.Fatalf("unexpected report: %v", err)
+	}
+}
+
+func TestIsSecretKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"DB_PASSWORD":          true,
+		"HADE_CONFIG_KEY":      true,
+		"KEY":                  true,
+		"api_key":              true,
+		"MYSQL_PWD":            true,
+		"GITHUB_TOKEN":         true,
+		"AWS_SECRET_ACCESS_ID": true,
+		"PWD":                  false,
+		"KEYBOARD":             false,
+		"MONKEY":               false,
+		"SSH_KEY_PATH":         false,
+		"HADE_CONFIG_KEY_FILE": false,
+		"APP_ENV":              false,
+	} {
+		if got := IsSecretKey(key); got != want {
+			t.Fatalf("IsSecretKey(%s) = %v, want %v", key, got, want)
+		}
+	}
+}
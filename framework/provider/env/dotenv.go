@@ -0,0 +1,243 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// dotenvParser 解析 .env 文件的内容
+type dotenvParser struct {
+	src    []rune
+	pos    int
+	line   int
+	lookup func(key string) (string, bool) // 查找 ${VAR} 引用的变量
+}
+
+// ParseDotenv 解析 .env 文件的内容，支持以下格式：
+//
+//	KEY=value # 行尾注释
+//	export KEY=value
+//	KEY='单引号中的内容不转义，不替换变量，可以换行'
+//	KEY="双引号中支持 \n \t \" \\ \$ 转义和 ${VAR} 替换，可以换行"
+//	KEY=${VAR}、$VAR 或者 ${VAR:-default}
+//
+// 引用的变量先使用 lookup 查找，再查找前面已经解析的变量，都不存在的时候为空字符串
+// 格式错误的行会被跳过，其他行的变量仍然返回，同时返回的 error 中列出所有跳过的行
+func ParseDotenv(content []byte, lookup func(key string) (string, bool)) (map[string]string, error) {
+	p := &dotenvParser{src: []rune(strings.ReplaceAll(string(content), "\r\n", "\n")), line: 1}
+	ret := map[string]string{}
+	p.lookup = func(key string) (string, bool) {
+		if lookup != nil {
+			if val, ok := lookup(key); ok {
+				return val, true
+			}
+		}
+		val, ok := ret[key]
+		return val, ok
+	}
+
+	var errs []string
+	for {
+		p.skipBlank()
+		if p.eof() {
+			break
+		}
+		if p.peek() == '#' {
+			p.skipLine()
+			continue
+		}
+		pos, line := p.pos, p.line
+		key, val, err := p.parseLine()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("line %d: %v", line, err))
+			// 只跳过出错的这一行，比如没有结束的引号不会吞掉后面所有的行
+			p.pos, p.line = pos, line
+			p.skipLine()
+			continue
+		}
+		ret[key] = val
+	}
+	if len(errs) > 0 {
+		return ret, errors.New(strings.Join(errs, "; "))
+	}
+	return ret, nil
+}
+
+func (p *dotenvParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *dotenvParser) peek() rune {
+	return p.src[p.pos]
+}
+
+func (p *dotenvParser) next() rune {
+	r := p.src[p.pos]
+	p.pos++
+	if r == '\n' {
+		p.line++
+	}
+	return r
+}
+
+// skipBlank 跳过空白字符和空行
+func (p *dotenvParser) skipBlank() {
+	for !p.eof() && strings.ContainsRune(" \t\n", p.peek()) {
+		p.next()
+	}
+}
+
+// skipSpace 跳过一行中的空格
+func (p *dotenvParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+func (p *dotenvParser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+func (p *dotenvParser) parseLine() (string, string, error) {
+	key := p.parseKey()
+	if key == "export" && !p.eof() && p.peek() == ' ' {
+		p.skipSpace()
+		key = p.parseKey()
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("invalid variable name")
+	}
+	p.skipSpace()
+	if p.eof() || p.peek() != '=' {
+		return "", "", fmt.Errorf("missing = after %s", key)
+	}
+	p.next()
+	p.skipSpace()
+
+	var val string
+	var err error
+	if !p.eof() && (p.peek() == '\'' || p.peek() == '"') {
+		val, err = p.parseQuoted(p.next())
+		if err != nil {
+			return "", "", err
+		}
+		// 引号之后只能有注释
+		p.skipSpace()
+		if !p.eof() && p.peek() != '\n' && p.peek() != '#' {
+			return "", "", fmt.Errorf("unexpected character %q after quoted value of %s", p.peek(), key)
+		}
+		p.skipLine()
+		return key, val, nil
+	}
+	return key, p.parseUnquoted(), nil
+}
+
+func (p *dotenvParser) parseKey() string {
+	start := p.pos
+	for !p.eof() {
+		r := p.peek()
+		if r == '_' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || p.pos > start && r >= '0' && r <= '9' {
+			p.next()
+			continue
+		}
+		break
+	}
+	return string(p.src[start:p.pos])
+}
+
+// parseUnquoted 解析没有引号的值，空格之后的 # 为注释，值前后的空格会被去掉
+func (p *dotenvParser) parseUnquoted() string {
+	var sb strings.Builder
+	for !p.eof() && p.peek() != '\n' {
+		r := p.next()
+		if r == '#' && (sb.Len() == 0 || strings.HasSuffix(sb.String(), " ") || strings.HasSuffix(sb.String(), "\t")) {
+			p.skipLine()
+			break
+		}
+		sb.WriteRune(r)
+	}
+	return p.expand(strings.TrimSpace(sb.String()))
+}
+
+// parseQuoted 解析引号中的值，双引号中的值支持转义和变量替换
+func (p *dotenvParser) parseQuoted(quote rune) (string, error) {
+	start := p.line
+	// out 为已经替换变量的内容，raw 为还没有替换变量的内容，转义的 \$ 直接写入 out
+	var out, raw strings.Builder
+	for !p.eof() {
+		r := p.next()
+		switch {
+		case r == quote:
+			if quote == '\'' {
+				return raw.String(), nil
+			}
+			out.WriteString(p.expand(raw.String()))
+			return out.String(), nil
+		case r == '\\' && quote == '"' && !p.eof():
+			switch e := p.next(); e {
+			case 'n':
+				raw.WriteRune('\n')
+			case 'r':
+				raw.WriteRune('\r')
+			case 't':
+				raw.WriteRune('\t')
+			case '$':
+				out.WriteString(p.expand(raw.String()))
+				out.WriteRune('$')
+				raw.Reset()
+			default:
+				raw.WriteRune(e)
+			}
+		default:
+			raw.WriteRune(r)
+		}
+	}
+	return "", fmt.Errorf("unterminated quoted value starting at line %d", start)
+}
+
+// expand 替换值中的 ${VAR}、${VAR:-default} 和 $VAR
+func (p *dotenvParser) expand(s string) string {
+	var sb strings.Builder
+	rs := []rune(s)
+	for i := 0; i < len(rs); i++ {
+		if rs[i] != '$' || i+1 >= len(rs) {
+			sb.WriteRune(rs[i])
+			continue
+		}
+		if rs[i+1] == '{' {
+			end := i + 2
+			for end < len(rs) && rs[end] != '}' {
+				end++
+			}
+			if end >= len(rs) {
+				sb.WriteRune(rs[i])
+				continue
+			}
+			name, def := string(rs[i+2:end]), ""
+			if n := strings.Index(name, ":-"); n >= 0 {
+				name, def = name[:n], name[n+2:]
+			}
+			if val, ok := p.lookup(name); ok && val != "" {
+				sb.WriteString(val)
+			} else {
+				sb.WriteString(def)
+			}
+			i = end
+			continue
+		}
+		end := i + 1
+		for end < len(rs) && (rs[end] == '_' || rs[end] >= 'a' && rs[end] <= 'z' || rs[end] >= 'A' && rs[end] <= 'Z' || end > i+1 && rs[end] >= '0' && rs[end] <= '9') {
+			end++
+		}
+		if end == i+1 {
+			sb.WriteRune(rs[i])
+			continue
+		}
+		val, _ := p.lookup(string(rs[i+1 : end]))
+		sb.WriteString(val)
+		i = end - 1
+	}
+	return sb.String()
+}
@@ -0,0 +1,98 @@
+package env
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	content := `# 注释
+export APP_NAME=hade # 行尾注释
+EMPTY=
+HASH=a#b
+SINGLE='raw ${APP_NAME} \n'
+DOUBLE="line1\nline2 \"q\" \$HOME ${APP_NAME}"
+MULTI="first
+second"
+URL=http://${HOST:-localhost}:$PORT/
+`
+	envs, err := ParseDotenv([]byte(content), func(key string) (string, bool) {
+		if key == "PORT" {
+			return "8080", true
+		}
+		return "", false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"APP_NAME": "hade",
+		"EMPTY":    "",
+		"HASH":     "a#b",
+		"SINGLE":   `raw ${APP_NAME} \n`,
+		"DOUBLE":   "line1\nline2 \"q\" $HOME hade",
+		"MULTI":    "first\nsecond",
+		"URL":      "http://localhost:8080/",
+	}
+	for key, val := range want {
+		if envs[key] != val {
+			t.Fatalf("%s = %q, want %q", key, envs[key], val)
+		}
+	}
+
+	// 格式错误的行被跳过，其他行仍然可以读取
+	envs, err = ParseDotenv([]byte("A=1\nB=\"unterminated\nnot a variable\nC=3\n"), nil)
+	if err == nil || !strings.Contains(err.Error(), "line 2") || !strings.Contains(err.Error(), "line 3") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(envs) != 2 || envs["A"] != "1" || envs["C"] != "3" {
+		t.Fatalf("unexpected envs: %v", envs)
+	}
+}
+
+func TestNewHadeEnv_Files(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-env")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	files := map[string]string{
+		".env":         "A=base\nB=base\nINVALID LINE\nC=base\nD=base\n",
+		".env.testing": "B=testing\nC=testing\n",
+		".env.prod":    "B=prod\n",
+		".env.local":   "APP_ENV=testing\nC=local\nE=${B}\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(folder, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Unsetenv("APP_ENV")
+	os.Setenv("D", "os")
+	defer os.Unsetenv("D")
+
+	ins, err := NewHadeEnv(folder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := ins.(*HadeEnv)
+	want := map[string]string{"APP_ENV": "testing", "A": "base", "B": "testing", "C": "local", "D": "os", "E": "testing"}
+	for key, val := range want {
+		if got := env.Get(key); got != val {
+			t.Fatalf("%s = %q, want %q", key, got, val)
+		}
+	}
+
+	// 指定 APP_ENV 的时候读取对应环境的 .env 文件
+	ins, err = NewHadeEnv(folder, nil, "prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env = ins.(*HadeEnv)
+	if env.AppEnv() != "prod" || env.Get("B") != "prod" {
+		t.Fatalf("APP_ENV = %s, B = %s, want prod", env.AppEnv(), env.Get("B"))
+	}
+}
@@ -7,10 +7,26 @@ import (
 
 type HadeEnvProvider struct {
 	Folder string
+	// Vars 应用声明的环境变量，必须设置的变量没有设置或者格式错误的时候创建 env 服务失败，返回包含所有问题的 *CheckError
+	Vars []contract.EnvVar
+	// SkipCheck 为 true 的时候声明的环境变量有问题也创建 env 服务，hade env check 使用它输出完整的报告
+	SkipCheck bool
+	// AppEnv 指定 APP_ENV，为空的时候从运行环境和 .env 文件中读取
+	AppEnv string
 }
 
 func (e *HadeEnvProvider) Register(container framework.Container) framework.NewInstance {
-	return NewHadeEnv
+	return func(params ...interface{}) (interface{}, error) {
+		ins, err := NewHadeEnv(params...)
+		if err != nil || e.SkipCheck {
+			return ins, err
+		}
+		// 启动的时候一次报告所有有问题的环境变量
+		if err := CheckEnv(ins.(contract.Env)); err != nil {
+			return nil, err
+		}
+		return ins, nil
+	}
 }
 
 func (e *HadeEnvProvider) Boot(container framework.Container) error {
@@ -24,9 +40,14 @@ func (e *HadeEnvProvider) IsDefer() bool {
 }
 
 func (e *HadeEnvProvider) Params(container framework.Container) []interface{} {
-	return []interface{}{e.Folder}
+	return []interface{}{e.Folder, e.Vars, e.AppEnv}
 }
 
 func (e *HadeEnvProvider) Name() string {
 	return contract.EnvKey
 }
+
+// Depends env 服务需要通过 app 服务获取 .env 所在目录
+func (e *HadeEnvProvider) Depends() []string {
+	return []string{contract.AppKey}
+}
@@ -1,12 +1,11 @@
 package env
 
 import (
-	"bufio"
-	"bytes"
 	"errors"
-	"io"
+	"io/ioutil"
+	"log"
 	"os"
-	"path"
+	"path/filepath"
 	"strings"
 
 	"github.com/yefangyong/go-frame/framework/contract"
@@ -15,6 +14,7 @@ import (
 type HadeEnv struct {
 	folder string            // 代表.env所在的目录
 	maps   map[string]string // 代表所有的环境变量
+	vars   []contract.EnvVar // 应用声明的环境变量
 }
 
 func (h *HadeEnv) AppEnv() string {
@@ -37,8 +37,12 @@ func (h *HadeEnv) All() map[string]string {
 	return h.maps
 }
 
+// NewHadeEnv 读取 .env 文件和运行环境的环境变量，优先级从低到高为 .env、.env.<APP_ENV>、.env.local、运行环境的环境变量
+// APP_ENV 可以在运行环境、.env.local 或者 .env 中设置，默认为 development
+// 第二个参数为应用声明的环境变量，有问题的变量不会导致创建失败，通过 Check 获取，HadeEnvProvider 使用 CheckEnv 检查
+// 第三个参数不为空的时候指定 APP_ENV，不再从运行环境和 .env 文件中读取，比如比较其他环境的配置
 func NewHadeEnv(params ...interface{}) (interface{}, error) {
-	if len(params) != 1 {
+	if len(params) < 1 || len(params) > 3 {
 		return nil, errors.New("NewHadeEnv params error")
 	}
 
@@ -50,45 +54,84 @@ func NewHadeEnv(params ...interface{}) (interface{}, error) {
 		folder: folder,
 		maps:   map[string]string{"APP_ENV": contract.EnvDevelopment}, // 默认为开发环境
 	}
-
-	// 解析 folder/.env 文件
-	file := path.Join(folder, ".env")
-
-	fi, err := os.Open(file)
-
-	if err == nil {
-		defer fi.Close()
-
-		// 读取文件
-		br := bufio.NewReader(fi)
-		for {
-			line, _, c := br.ReadLine()
-			if c == io.EOF {
-				break
-			}
-
-			//按照等号进行解析
-			s := bytes.SplitN(line, []byte{'='}, 2)
-
-			// 如果不符合规范，则过滤
-			if len(s) < 2 {
-				continue
-			}
-
-			// 保存 map
-			key := string(s[0])
-			val := string(s[1])
-			hadeEnv.maps[key] = val
-		}
+	if len(params) >= 2 {
+		hadeEnv.vars, _ = params[1].([]contract.EnvVar)
+	}
+	var appEnv string
+	if len(params) == 3 {
+		appEnv, _ = params[2].(string)
 	}
 
-	// 获取当前程序的环境变量，并且覆盖 .env 文件下的变量
+	// 获取当前程序的环境变量，会覆盖 .env 文件中的变量
+	osEnvs := map[string]string{}
 	for _, e := range os.Environ() {
 		pair := strings.SplitN(e, "=", 2)
 		if len(pair) < 2 {
 			continue
 		}
-		hadeEnv.maps[pair[0]] = pair[1]
+		osEnvs[pair[0]] = pair[1]
+	}
+
+	if err := hadeEnv.loadDotenv(".env", osEnvs); err != nil {
+		return nil, err
+	}
+	// 先读取 .env.local 确定 APP_ENV，.env.<APP_ENV> 读取之后再重新读取 .env.local 覆盖
+	if appEnv != "" {
+		// 指定的 APP_ENV 和运行环境的环境变量一样覆盖所有 .env 文件
+		osEnvs["APP_ENV"] = appEnv
+	} else if appEnv = osEnvs["APP_ENV"]; appEnv == "" {
+		local, err := hadeEnv.parseDotenv(".env.local", osEnvs)
+		if err != nil {
+			return nil, err
+		}
+		if appEnv = local["APP_ENV"]; appEnv == "" {
+			appEnv = hadeEnv.maps["APP_ENV"]
+		}
+	}
+	for _, file := range []string{".env." + appEnv, ".env.local"} {
+		if err := hadeEnv.loadDotenv(file, osEnvs); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, val := range osEnvs {
+		hadeEnv.maps[key] = val
 	}
 	return hadeEnv, nil
 }
+
+// loadDotenv 读取 folder 中的 dotenv 文件并且覆盖已经读取的变量
+func (h *HadeEnv) loadDotenv(file string, osEnvs map[string]string) error {
+	envs, err := h.parseDotenv(file, osEnvs)
+	if err != nil {
+		return err
+	}
+	for key, val := range envs {
+		h.maps[key] = val
+	}
+	return nil
+}
+
+// parseDotenv 解析 folder 中的 dotenv 文件，文件不存在的时候返回空，格式错误的行会被跳过
+// 变量中的 ${VAR} 依次使用运行环境的环境变量、已经读取的变量替换
+func (h *HadeEnv) parseDotenv(file string, osEnvs map[string]string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(filepath.Join(h.folder, file))
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	envs, err := ParseDotenv(content, func(key string) (string, bool) {
+		if val, ok := osEnvs[key]; ok {
+			return val, true
+		}
+		val, ok := h.maps[key]
+		return val, ok
+	})
+	if err != nil {
+		// 和之前一样忽略格式错误的行，只打印警告，不影响应用启动
+		log.Printf("忽略 %s 中格式错误的行：%v", file, err)
+	}
+	return envs, nil
+}
@@ -0,0 +1,151 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/orm"
+	"github.com/yefangyong/go-frame/framework/provider/redis"
+)
+
+// defaultMinFreeDisk 运行目录所在磁盘默认最少的剩余空间 100MB
+const defaultMinFreeDisk = 100 << 20
+
+// checker 使用函数实现的检查项
+type checker struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+func (c *checker) Name() string {
+	return c.name
+}
+
+func (c *checker) Check(ctx context.Context) error {
+	return c.check(ctx)
+}
+
+// NewChecker 使用函数创建一个检查项
+func NewChecker(name string, check func(ctx context.Context) error) contract.HealthChecker {
+	return &checker{name: name, check: check}
+}
+
+// defaultCheckers 根据容器中绑定的服务和配置生成框架内置的检查项
+func defaultCheckers(container framework.Container) []contract.HealthChecker {
+	var checkers []contract.HealthChecker
+	if container.IsBind(contract.ORMKEY) {
+		checkers = append(checkers, DBCheckers(container)...)
+	}
+	if container.IsBind(contract.RedisKey) {
+		checkers = append(checkers, RedisCheckers(container)...)
+	}
+	if container.IsBind(contract.AppKey) {
+		checkers = append(checkers, DiskChecker(container, defaultMinFreeDisk))
+	}
+	return checkers
+}
+
+// DBCheckers 为 database 配置中的每个连接生成一个 ping 检查项，名称为 db:<连接名>
+func DBCheckers(container framework.Container) []contract.HealthChecker {
+	configService := container.MustMake(contract.ConfigKey).(contract.Config)
+	var names []string
+	for name, val := range configService.GetStringMap("database") {
+		if _, ok := val.(map[string]interface{}); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	checkers := make([]contract.HealthChecker, 0, len(names))
+	for _, name := range names {
+		path := "database." + name
+		checkers = append(checkers, NewChecker("db:"+name, func(ctx context.Context) error {
+			ormService := container.MustMake(contract.ORMKEY).(contract.ORMService)
+			db, err := ormService.GetDB(orm.WithConfigPath(path))
+			if err != nil {
+				return err
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		}))
+	}
+	return checkers
+}
+
+// RedisCheckers 为 redis 和 cache.redis 配置生成 PING 检查项，名称为 redis:<配置路径>
+// 可以通过 app.health.redis 指定需要检查的配置路径
+func RedisCheckers(container framework.Container) []contract.HealthChecker {
+	configService := container.MustMake(contract.ConfigKey).(contract.Config)
+	paths := configService.GetStringSlice("app.health.redis")
+	if !configService.IsExist("app.health.redis") {
+		for _, path := range []string{"redis", "cache.redis"} {
+			if configService.IsExist(path) {
+				paths = append(paths, path)
+			}
+		}
+	}
+
+	checkers := make([]contract.HealthChecker, 0, len(paths))
+	for _, path := range paths {
+		path := path
+		checkers = append(checkers, NewChecker("redis:"+path, func(ctx context.Context) error {
+			redisService := container.MustMake(contract.RedisKey).(contract.RedisService)
+			client, err := redisService.GetClient(redis.WithConfigPath(path))
+			if err != nil {
+				return err
+			}
+			return client.Ping(ctx).Err()
+		}))
+	}
+	return checkers
+}
+
+// DiskChecker 检查运行目录所在磁盘的剩余空间，可以通过 app.health.disk_min_free 设置最少的剩余字节数
+func DiskChecker(container framework.Container, minFree uint64) contract.HealthChecker {
+	configService := container.MustMake(contract.ConfigKey).(contract.Config)
+	if configService.IsExist("app.health.disk_min_free") {
+		minFree = uint64(configService.GetInt("app.health.disk_min_free"))
+	}
+	return NewChecker("disk", func(ctx context.Context) error {
+		appService := container.MustMake(contract.AppKey).(contract.App)
+		folder, err := existFolder(appService.RuntimeFolder())
+		if err != nil {
+			return err
+		}
+		free, err := diskFree(folder)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%s free space %d bytes is less than %d bytes", folder, free, minFree)
+		}
+		return nil
+	})
+}
+
+// existFolder 运行目录可能还没有创建，向上查找第一个存在的目录
+func existFolder(folder string) (string, error) {
+	folder, err := filepath.Abs(folder)
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(folder); err == nil {
+			return folder, nil
+		}
+		parent := filepath.Dir(folder)
+		if parent == folder {
+			return "", errors.New("no exist folder for " + folder)
+		}
+		folder = parent
+	}
+}
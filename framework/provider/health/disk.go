@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package health
+
+import "syscall"
+
+// diskFree 返回目录所在磁盘非特权用户可用的剩余空间
+func diskFree(folder string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(folder, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
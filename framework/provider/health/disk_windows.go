@@ -0,0 +1,21 @@
+package health
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// diskFree 返回目录所在磁盘当前用户可用的剩余空间
+func diskFree(folder string) (uint64, error) {
+	path, err := syscall.UTF16PtrFromString(folder)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	proc := syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+	ret, _, err := proc.Call(uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(&free)), 0, 0)
+	if ret == 0 {
+		return 0, err
+	}
+	return free, nil
+}
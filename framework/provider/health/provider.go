@@ -0,0 +1,37 @@
+package health
+
+import (
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+// HadeHealthProvider 健康检查服务提供者
+type HadeHealthProvider struct {
+	// Checkers 额外的就绪检查项，框架内置的数据库、redis、磁盘检查项会根据配置自动注册
+	Checkers []contract.HealthChecker
+}
+
+func (h *HadeHealthProvider) Register(container framework.Container) framework.NewInstance {
+	return NewHadeHealth
+}
+
+func (h *HadeHealthProvider) Boot(container framework.Container) error {
+	return nil
+}
+
+func (h *HadeHealthProvider) IsDefer() bool {
+	return true
+}
+
+func (h *HadeHealthProvider) Params(container framework.Container) []interface{} {
+	return []interface{}{container, h.Checkers}
+}
+
+func (h *HadeHealthProvider) Name() string {
+	return contract.HealthKey
+}
+
+// Depends 健康检查服务需要读取配置来决定内置的检查项
+func (h *HadeHealthProvider) Depends() []string {
+	return []string{contract.ConfigKey}
+}
@@ -0,0 +1,149 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+// defaultTimeout 单个检查项默认的超时时间
+const defaultTimeout = 3 * time.Second
+
+// HadeHealth 健康检查服务
+type HadeHealth struct {
+	container framework.Container
+	timeout   time.Duration
+
+	lock      sync.RWMutex
+	liveness  []contract.HealthChecker
+	readiness []contract.HealthChecker
+}
+
+// NewHadeHealth 实例化健康检查服务，并注册框架内置的检查项
+func NewHadeHealth(params ...interface{}) (interface{}, error) {
+	container := params[0].(framework.Container)
+	checkers := params[1].([]contract.HealthChecker)
+
+	configService := container.MustMake(contract.ConfigKey).(contract.Config)
+	timeout := defaultTimeout
+	if configService.IsExist("app.health.timeout") {
+		t, err := time.ParseDuration(configService.GetString("app.health.timeout"))
+		if err != nil {
+			return nil, errors.New("app.health.timeout: " + err.Error())
+		}
+		timeout = t
+	}
+
+	h := &HadeHealth{container: container, timeout: timeout}
+	h.Register(defaultCheckers(container)...)
+	h.Register(checkers...)
+	return h, nil
+}
+
+func (h *HadeHealth) Register(checkers ...contract.HealthChecker) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.readiness = append(h.readiness, checkers...)
+}
+
+func (h *HadeHealth) RegisterLiveness(checkers ...contract.HealthChecker) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.liveness = append(h.liveness, checkers...)
+}
+
+func (h *HadeHealth) Liveness(ctx context.Context) *contract.HealthReport {
+	h.lock.RLock()
+	checkers := append([]contract.HealthChecker{}, h.liveness...)
+	h.lock.RUnlock()
+	return h.run(ctx, checkers)
+}
+
+func (h *HadeHealth) Readiness(ctx context.Context) *contract.HealthReport {
+	h.lock.RLock()
+	checkers := append([]contract.HealthChecker{}, h.liveness...)
+	checkers = append(checkers, h.readiness...)
+	h.lock.RUnlock()
+
+	// 带有 health.checker 标签的服务在每次检查的时候获取，这样后绑定的服务提供者也能生效
+	tagged, err := h.container.MakeTagged(contract.HealthCheckerTag)
+	if err != nil {
+		checkers = append(checkers, &checker{name: contract.HealthCheckerTag, check: func(ctx context.Context) error {
+			return err
+		}})
+	}
+	for _, ins := range tagged {
+		switch c := ins.(type) {
+		case contract.HealthChecker:
+			checkers = append(checkers, c)
+		case []contract.HealthChecker:
+			checkers = append(checkers, c...)
+		default:
+			name := fmt.Sprintf("%T", ins)
+			checkers = append(checkers, &checker{name: name, check: func(ctx context.Context) error {
+				return errors.New(name + " tagged " + contract.HealthCheckerTag + " is not a HealthChecker")
+			}})
+		}
+	}
+	return h.run(ctx, checkers)
+}
+
+// run 并发执行所有检查项，结果按照检查项的顺序返回
+func (h *HadeHealth) run(ctx context.Context, checkers []contract.HealthChecker) *contract.HealthReport {
+	report := &contract.HealthReport{
+		Status: contract.HealthStatusUp,
+		Checks: make([]contract.HealthCheckResult, len(checkers)),
+	}
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func(i int, c contract.HealthChecker) {
+			defer wg.Done()
+			report.Checks[i] = h.check(ctx, c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != contract.HealthStatusUp {
+			report.Status = contract.HealthStatusDown
+		}
+	}
+	return report
+}
+
+// check 执行单个检查项，检查项 panic 或者超时都视为检查失败
+func (h *HadeHealth) check(ctx context.Context, c contract.HealthChecker) (result contract.HealthCheckResult) {
+	result = contract.HealthCheckResult{Name: c.Name(), Status: contract.HealthStatusUp}
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				done <- fmt.Errorf("panic: %v", e)
+			}
+		}()
+		done <- c.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	result.Duration = time.Since(start).String()
+	if err != nil {
+		result.Status = contract.HealthStatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,50 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/gin"
+	"github.com/yefangyong/go-frame/framework/middleware"
+	hadetest "github.com/yefangyong/go-frame/framework/testing"
+)
+
+func TestHadeHealth(t *testing.T) {
+	tc := hadetest.NewTestContainer(t, hadetest.WithConfig(map[string]interface{}{
+		"app": map[string]interface{}{
+			"health": map[string]interface{}{"timeout": "50ms", "disk_min_free": 1},
+		},
+	}))
+	_ = tc.Bind(&HadeHealthProvider{Checkers: []contract.HealthChecker{
+		NewChecker("ok", func(ctx context.Context) error { return nil }),
+	}})
+	r := tc.NewEngine(func(r *gin.Engine) error {
+		r.GET("/healthz", middleware.HealthLiveness())
+		r.GET("/readyz", middleware.HealthReadiness())
+		return nil
+	})
+
+	r.GET("/healthz").Do().AssertStatus(200).AssertJSONPath("status", "up")
+	r.GET("/readyz").Do().
+		AssertStatus(200).
+		AssertJSONPath("checks.0.name", "disk").
+		AssertJSONPath("checks.1.name", "ok")
+
+	health := tc.MustMake(contract.HealthKey).(contract.Health)
+	health.Register(NewChecker("broken", func(ctx context.Context) error { return errors.New("connection refused") }))
+	health.Register(NewChecker("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}))
+	health.Register(NewChecker("panic", func(ctx context.Context) error { panic("boom") }))
+
+	r.GET("/readyz").Do().
+		AssertStatus(503).
+		AssertJSONPath("status", "down").
+		AssertJSONPath("checks.2.error", "connection refused").
+		AssertJSONPath("checks.3.status", "down").
+		AssertJSONPath("checks.4.error", "panic: boom")
+	r.GET("/healthz").Do().AssertStatus(200)
+}
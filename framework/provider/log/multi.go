@@ -0,0 +1,116 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cast"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/log/services"
+)
+
+// sinkDrivers multi 驱动中每个输出可以使用的日志驱动
+var sinkDrivers = map[string]framework.NewInstance{
+	"console": services.NewHadeConsoleLog,
+	"single":  services.NewHadeSingleLog,
+	"rotate":  services.NewHadeRotateLog,
+	"custom":  services.NewHadeCustomLog,
+}
+
+// newMultiLog 创建 multi 驱动，log.sinks 中的每一项为一个输出，比如：
+//
+//	driver: multi
+//	level: debug
+//	sinks:
+//	  - driver: console
+//	    level: info
+//	  - driver: rotate
+//	    level: error
+//	    formatter: json
+//	    file: error.log
+//	    rotate_count: 7
+//
+// 每个输出的 level 和 formatter 默认使用 log 中的设置，log.level 同时是所有输出的最高日志级别
+// single 和 rotate 输出的 folder、file、rotate_count 等设置和 log 中的设置相同，但是只作用于这个输出
+// 每个输出都异步写入，缓冲区满了之后默认和 log.async_overflow 一样阻塞，不会丢失日志
+// 不希望慢的输出阻塞调用方的时候可以设置输出的 async_overflow 为 drop_oldest 或者 drop_new，丢弃的行数通过 Dropped 获取
+// 修改每个输出的 level 和 formatter 之后马上生效，增加、删除输出或者修改输出的 driver、文件等设置需要重启之后生效
+func (h *HadeLogServiceProvider) newMultiLog(params ...interface{}) (interface{}, error) {
+	container := params[0].(framework.Container)
+	level := params[1].(contract.LogLevel)
+	logFormatter := params[3].(contract.Formatter)
+	configService := container.MustMake(contract.ConfigKey).(contract.Config)
+
+	items, ok := configService.Get("log.sinks").([]interface{})
+	if !ok || len(items) == 0 {
+		return nil, errors.New("log.sinks should be a list of sinks when log driver is multi")
+	}
+	drivers := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		settings := cast.ToStringMap(item)
+		name := strings.ToLower(cast.ToString(settings["driver"]))
+		newSink, ok := sinkDrivers[name]
+		if !ok {
+			return nil, fmt.Errorf("log.sinks.%d: driver %s is not supported", i, name)
+		}
+		if name == "custom" && h.Output == nil {
+			return nil, fmt.Errorf("log.sinks.%d: custom driver needs Output of HadeLogServiceProvider", i)
+		}
+		sinkLevel := level
+		if v := cast.ToString(settings["level"]); v != "" {
+			if sinkLevel = GetLevel(v); sinkLevel == contract.UnknownLevel {
+				return nil, fmt.Errorf("log.sinks.%d: level %s is not supported", i, v)
+			}
+		}
+		sinkFormatter := logFormatter
+		if v := cast.ToString(settings["formatter"]); v != "" {
+			sinkFormatter = formatterOf(v)
+		}
+		opts, err := asyncOptions(settings, services.OverflowBlock)
+		if err != nil {
+			return nil, fmt.Errorf("log.sinks.%d: %v", i, err)
+		}
+
+		ins, err := newSink(container, sinkLevel, contract.CtxFielder(nil), sinkFormatter, h.Output, settings)
+		if err != nil {
+			return nil, errors.Wrapf(err, "log.sinks.%d", i)
+		}
+		ins.(interface{ EnableAsync(services.AsyncOptions) }).EnableAsync(opts)
+		drivers = append(drivers, ins)
+	}
+	return services.NewHadeMultiLog(container, level, params[2], logFormatter, h.Output, drivers)
+}
+
+// updateSinks 在 log 配置变化之后按照 log.sinks 中的顺序更新每个输出的日志级别和输出格式
+// 不支持的日志级别会被忽略，保留原来的日志级别
+func (h *HadeLogServiceProvider) updateSinks(configService contract.Config, sinks []contract.Log) {
+	items, _ := configService.Get("log.sinks").([]interface{})
+	level, logFormatter := h.Level, h.Formatter
+	if level == contract.UnknownLevel {
+		level = configLevel(configService)
+	}
+	if logFormatter == nil {
+		logFormatter = configFormatter(configService)
+	}
+	for i, sink := range sinks {
+		if i >= len(items) {
+			break
+		}
+		settings := cast.ToStringMap(items[i])
+		sinkLevel := level
+		if v := cast.ToString(settings["level"]); v != "" {
+			sinkLevel = GetLevel(v)
+		}
+		if sinkLevel != contract.UnknownLevel {
+			sink.SetLevel(sinkLevel)
+		}
+		sinkFormatter := logFormatter
+		if v := cast.ToString(settings["formatter"]); v != "" {
+			sinkFormatter = formatterOf(v)
+		}
+		sink.SetFormatter(sinkFormatter)
+	}
+}
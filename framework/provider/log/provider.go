@@ -1,9 +1,12 @@
 package log
 
 import (
+	"fmt"
 	"io"
 	"strings"
 
+	"github.com/spf13/cast"
+
 	"github.com/yefangyong/go-frame/framework/provider/log/formatter"
 
 	"github.com/yefangyong/go-frame/framework/provider/log/services"
@@ -30,6 +33,58 @@ type HadeLogServiceProvider struct {
 }
 
 func (h *HadeLogServiceProvider) Register(container framework.Container) framework.NewInstance {
+	newLog := h.driver(container)
+	return func(params ...interface{}) (interface{}, error) {
+		ins, err := newLog(params...)
+		if err != nil {
+			return nil, err
+		}
+		if err := h.enableAsync(container, ins); err != nil {
+			return nil, err
+		}
+		h.watchConfig(container, ins.(contract.Log))
+		return ins, nil
+	}
+}
+
+// enableAsync 配置了 log.async 的时候开启异步写入，缓冲区的设置从以下配置中读取：
+//   - log.async_size 缓冲区可以保存的日志行数
+//   - log.async_batch 每次最多合并写入的日志行数
+//   - log.async_overflow 缓冲区满了之后的处理方式，block、drop_oldest 或者 drop_new
+func (h *HadeLogServiceProvider) enableAsync(container framework.Container, ins interface{}) error {
+	configService := container.MustMake(contract.ConfigKey).(contract.Config)
+	if !configService.GetBool("log.async") {
+		return nil
+	}
+	opts, err := asyncOptions(configService.GetStringMap("log"), services.OverflowBlock)
+	if err != nil {
+		return fmt.Errorf("log.%v", err)
+	}
+	if logger, ok := ins.(interface{ EnableAsync(services.AsyncOptions) }); ok {
+		logger.EnableAsync(opts)
+	}
+	return nil
+}
+
+// asyncOptions 读取 async_size、async_batch 和 async_overflow 设置，没有设置 async_overflow 的时候使用 overflow
+func asyncOptions(settings map[string]interface{}, overflow string) (services.AsyncOptions, error) {
+	if v := strings.ToLower(cast.ToString(settings["async_overflow"])); v != "" {
+		overflow = v
+	}
+	switch overflow {
+	case services.OverflowBlock, services.OverflowDropOldest, services.OverflowDropNew:
+	default:
+		return services.AsyncOptions{}, fmt.Errorf("async_overflow %s is not supported, use block, drop_oldest or drop_new", overflow)
+	}
+	return services.AsyncOptions{
+		Size:     cast.ToInt(settings["async_size"]),
+		Batch:    cast.ToInt(settings["async_batch"]),
+		Overflow: overflow,
+	}, nil
+}
+
+// driver 根据不同的驱动返回日志服务的实例化方法
+func (h *HadeLogServiceProvider) driver(container framework.Container) framework.NewInstance {
 	if h.Driver == "" {
 		configServicePro, err := container.Make(contract.ConfigKey)
 		if err != nil {
@@ -48,6 +103,8 @@ func (h *HadeLogServiceProvider) Register(container framework.Container) framewo
 		return services.NewHadeCustomLog
 	case "console":
 		return services.NewHadeConsoleLog
+	case "multi":
+		return h.newMultiLog
 	default:
 		return services.NewHadeConsoleLog
 	}
@@ -64,32 +121,69 @@ func (h *HadeLogServiceProvider) IsDefer() bool {
 func (h *HadeLogServiceProvider) Params(container framework.Container) []interface{} {
 	// 获取 configService
 	configService := container.MustMake(contract.ConfigKey).(contract.Config)
-	if h.Formatter == nil {
-		h.Formatter = formatter.TextFormatter
-		if configService.IsExist("log.formatter") {
-			v := configService.GetString("log.formatter")
-			if v == "json" {
-				h.Formatter = formatter.JsonFormatter
-			} else if v == "text" {
-				h.Formatter = formatter.TextFormatter
-			}
-		}
+	// 没有设置的时候从配置中读取，不修改服务提供者本身，这样配置热更新的时候可以判断哪些是从配置中读取的
+	logFormatter := h.Formatter
+	if logFormatter == nil {
+		logFormatter = configFormatter(configService)
 	}
-
-	if h.Level == contract.UnknownLevel {
-		h.Level = contract.InfoLevel
-		if configService.IsExist("log.level") {
-			h.Level = GetLevel(configService.GetString("log.level"))
-		}
+	level := h.Level
+	if level == contract.UnknownLevel {
+		level = configLevel(configService)
 	}
 	// 定义五个参数
-	return []interface{}{container, h.Level, h.CtxFielder, h.Formatter, h.Output}
+	return []interface{}{container, level, h.CtxFielder, logFormatter, h.Output}
 }
 
 func (h *HadeLogServiceProvider) Name() string {
 	return contract.LogKey
 }
 
+// Depends 日志服务需要读取配置，文件类的日志驱动还需要 app 服务获取日志目录
+func (h *HadeLogServiceProvider) Depends() []string {
+	return []string{contract.AppKey, contract.ConfigKey}
+}
+
+// configFormatter 读取配置中的 log.formatter，默认为 text
+func configFormatter(configService contract.Config) contract.Formatter {
+	return formatterOf(configService.GetString("log.formatter"))
+}
+
+// formatterOf 返回名称对应的输出格式，json 或者 text，默认为 text
+func formatterOf(name string) contract.Formatter {
+	if strings.ToLower(name) == "json" {
+		return formatter.JsonFormatter
+	}
+	return formatter.TextFormatter
+}
+
+// configLevel 读取配置中的 log.level，默认为 info
+func configLevel(configService contract.Config) contract.LogLevel {
+	if configService.IsExist("log.level") {
+		return GetLevel(configService.GetString("log.level"))
+	}
+	return contract.InfoLevel
+}
+
+// watchConfig 订阅 log 配置的变化，更新从配置中读取的日志级别和输出格式，multi 驱动同时更新每个输出的设置
+func (h *HadeLogServiceProvider) watchConfig(container framework.Container, logger contract.Log) {
+	multi, isMulti := logger.(*services.HadeMultiLog)
+	if h.Level != contract.UnknownLevel && h.Formatter != nil && !isMulti {
+		return
+	}
+	configService := container.MustMake(contract.ConfigKey).(contract.Config)
+	configService.Watch("log", func(oldVal, newVal interface{}) {
+		if h.Level == contract.UnknownLevel {
+			logger.SetLevel(configLevel(configService))
+		}
+		if h.Formatter == nil {
+			logger.SetFormatter(configFormatter(configService))
+		}
+		if isMulti {
+			h.updateSinks(configService, multi.Sinks())
+		}
+	})
+}
+
 func GetLevel(level string) contract.LogLevel {
 	switch strings.ToLower(level) {
 	case "panic":
@@ -0,0 +1,336 @@
+package log_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/log"
+	"github.com/yefangyong/go-frame/framework/provider/log/formatter"
+	"github.com/yefangyong/go-frame/framework/provider/log/services"
+	hadetest "github.com/yefangyong/go-frame/framework/testing"
+)
+
+func TestHadeLogServiceProvider_WatchConfig(t *testing.T) {
+	config := hadetest.NewMemoryConfig(map[string]interface{}{
+		"log": map[string]interface{}{"level": "info"},
+	})
+	tc := hadetest.NewTestContainer(t)
+	tc.Override(contract.ConfigKey, config)
+	output := &hadetest.LogCapture{}
+	_ = tc.Bind(&log.HadeLogServiceProvider{Driver: "custom", Output: output})
+
+	logger := tc.MustMake(contract.LogKey).(contract.Log)
+	logger.Debug(context.Background(), "before", map[string]interface{}{})
+	if err := config.Set("log.level", "debug"); err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug(context.Background(), "after", map[string]interface{}{})
+
+	if output.Contains("before") || !output.Contains("after") {
+		t.Fatalf("log level not reloaded: %v", output.Lines())
+	}
+}
+
+// blockingWriter 第一次写入的时候阻塞，直到 release 被关闭
+type blockingWriter struct {
+	hadetest.LogCapture
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() {
+		close(w.started)
+		<-w.release
+	})
+	return w.LogCapture.Write(p)
+}
+
+func TestHadeLogServiceProvider_Async(t *testing.T) {
+	config := hadetest.NewMemoryConfig(map[string]interface{}{
+		"log": map[string]interface{}{"level": "info", "async": true, "async_size": 2, "async_batch": 1, "async_overflow": "drop_new"},
+	})
+	tc := hadetest.NewTestContainer(t)
+	tc.Override(contract.ConfigKey, config)
+	output := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+	_ = tc.Bind(&log.HadeLogServiceProvider{Driver: "custom", Output: output})
+	logger := tc.MustMake(contract.LogKey).(contract.Log)
+
+	// 第一行被后台 goroutine 取出并阻塞在写入中，之后的两行放入缓冲区，剩下的被丢弃
+	logger.Info(context.Background(), "line-0", map[string]interface{}{})
+	<-output.started
+	for i := 1; i <= 5; i++ {
+		logger.Info(context.Background(), "line-"+strconv.Itoa(i), map[string]interface{}{})
+	}
+	if got := logger.(contract.LogDropCounter).Dropped(); got != 3 {
+		t.Fatalf("dropped = %d, want 3", got)
+	}
+
+	close(output.release)
+	if err := logger.(framework.Shutdowner).Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lines := output.Lines()
+	if len(lines) != 3 || !strings.Contains(lines[2], "line-2") {
+		t.Fatalf("pending lines not flushed on shutdown: %v", lines)
+	}
+}
+
+// gateWriter 第一次写入的时候阻塞，直到 release 被关闭，之后的写入不会等待第一次写入完成
+type gateWriter struct {
+	hadetest.LogCapture
+	started chan struct{}
+	release chan struct{}
+	writes  int32
+}
+
+func (w *gateWriter) Write(p []byte) (int, error) {
+	if atomic.AddInt32(&w.writes, 1) == 1 {
+		close(w.started)
+		<-w.release
+	}
+	return w.LogCapture.Write(p)
+}
+
+func TestAsyncWriter_WriteAfterClose(t *testing.T) {
+	output := &gateWriter{started: make(chan struct{}), release: make(chan struct{})}
+	w := services.NewAsyncWriter(output, services.AsyncOptions{Batch: 1})
+	_, _ = w.Write([]byte("line-0\n"))
+	<-output.started
+	_, _ = w.Write([]byte("line-1\n"))
+
+	// 关闭的时候缓冲区中还有日志，关闭之后写入的日志需要等它们写完
+	closed := make(chan error)
+	go func() {
+		closed <- w.Close(context.Background())
+	}()
+	time.Sleep(20 * time.Millisecond)
+	written := make(chan struct{})
+	go func() {
+		_, _ = w.Write([]byte("line-2\n"))
+		close(written)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(output.release)
+	if err := <-closed; err != nil {
+		t.Fatal(err)
+	}
+	<-written
+	if got := strings.Join(output.Lines(), ","); got != "line-0,line-1,line-2" {
+		t.Fatalf("unexpected output: %q", got)
+	}
+}
+
+func TestHadeLog_SetOutputAsync(t *testing.T) {
+	config := hadetest.NewMemoryConfig(map[string]interface{}{
+		"log": map[string]interface{}{"level": "info", "async": true},
+	})
+	tc := hadetest.NewTestContainer(t)
+	tc.Override(contract.ConfigKey, config)
+	first := &hadetest.LogCapture{}
+	_ = tc.Bind(&log.HadeLogServiceProvider{Driver: "custom", Output: first})
+	logger := tc.MustMake(contract.LogKey).(contract.Log)
+
+	// 替换 output 之前缓冲区中的日志写入原来的 output，之后的日志继续异步写入新的 output
+	logger.Info(context.Background(), "before", map[string]interface{}{})
+	second := &hadetest.LogCapture{}
+	logger.SetOutput(second)
+	if !first.Contains("before") {
+		t.Fatalf("pending lines not flushed to old output: %v", first.Lines())
+	}
+	logger.Info(context.Background(), "after", map[string]interface{}{})
+	if err := logger.(framework.Shutdowner).Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if first.Contains("after") || !second.Contains("after") {
+		t.Fatalf("line not written to new output: %v, %v", first.Lines(), second.Lines())
+	}
+}
+
+func TestHadeLog_With(t *testing.T) {
+	tc := hadetest.NewTestContainer(t)
+	tc.Override(contract.ConfigKey, hadetest.NewMemoryConfig(map[string]interface{}{}))
+	output := &hadetest.LogCapture{}
+	_ = tc.Bind(&log.HadeLogServiceProvider{
+		Driver:    "custom",
+		Level:     contract.InfoLevel,
+		Formatter: formatter.JsonFormatter,
+		Output:    output,
+		CtxFielder: func(ctx context.Context) map[string]interface{} {
+			return map[string]interface{}{"trace": "t1"}
+		},
+	})
+	logger := tc.MustMake(contract.LogKey).(contract.Log)
+
+	child := logger.With(map[string]interface{}{"module": "demo"})
+	grandchild := child.With(map[string]interface{}{"api": "cache"})
+	fields := map[string]interface{}{"user": 1}
+	grandchild.Info(context.Background(), "hello", fields)
+	child.Infow(context.Background(), "kv", "user", 2, "odd")
+	child.Infof(context.Background(), "count=%d", 3)
+	logger.Info(context.Background(), "root", nil)
+	child.Debugf(context.Background(), "hidden")
+
+	if len(fields) != 1 {
+		t.Fatalf("caller fields mutated: %v", fields)
+	}
+	lines := output.Lines()
+	if len(lines) != 4 {
+		t.Fatalf("unexpected lines: %v", lines)
+	}
+	for i, want := range [][]string{
+		{`"module":"demo"`, `"api":"cache"`, `"user":1`, `"trace":"t1"`},
+		{`"module":"demo"`, `"user":2`, `"odd":"(MISSING)"`},
+		{`count=3`, `"module":"demo"`},
+	} {
+		for _, s := range want {
+			if !strings.Contains(lines[i], s) {
+				t.Fatalf("line %d = %s, want %s", i, lines[i], s)
+			}
+		}
+	}
+	if strings.Contains(lines[3], "module") {
+		t.Fatalf("child fields leaked to parent: %s", lines[3])
+	}
+}
+
+func TestHadeLogServiceProvider_Multi(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	tc := hadetest.NewTestContainer(t)
+	tc.Override(contract.ConfigKey, hadetest.NewMemoryConfig(map[string]interface{}{
+		"log": map[string]interface{}{
+			"level": "debug",
+			"sinks": []interface{}{
+				map[string]interface{}{"driver": "custom", "level": "info"},
+				map[string]interface{}{"driver": "single", "level": "error", "formatter": "json", "folder": folder, "file": "error.log"},
+			},
+		},
+	}))
+	output := &hadetest.LogCapture{}
+	_ = tc.Bind(&log.HadeLogServiceProvider{Driver: "multi", Output: output})
+	logger := tc.MustMake(contract.LogKey).(contract.Log)
+
+	logger.Debug(context.Background(), "debug-line", nil)
+	logger.Info(context.Background(), "info-line", nil)
+	logger.With(map[string]interface{}{"module": "demo"}).Error(context.Background(), "error-line", nil)
+	if err := logger.(framework.Shutdowner).Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.Contains("debug-line") || !output.Contains("info-line") || !output.Contains("error-line") {
+		t.Fatalf("unexpected console sink lines: %v", output.Lines())
+	}
+	content, err := ioutil.ReadFile(filepath.Join(folder, "error.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"msg":"error-line"`) || !strings.Contains(lines[0], `"module":"demo"`) {
+		t.Fatalf("unexpected error sink content: %q", content)
+	}
+}
+
+func TestHadeLogServiceProvider_MultiPanic(t *testing.T) {
+	folder, err := ioutil.TempDir("", "hade-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	tc := hadetest.NewTestContainer(t)
+	tc.Override(contract.ConfigKey, hadetest.NewMemoryConfig(map[string]interface{}{
+		"log": map[string]interface{}{
+			"level": "info",
+			"sinks": []interface{}{
+				map[string]interface{}{"driver": "custom"},
+				map[string]interface{}{"driver": "single", "folder": folder, "file": "panic.log"},
+			},
+		},
+	}))
+	output := &hadetest.LogCapture{}
+	_ = tc.Bind(&log.HadeLogServiceProvider{Driver: "multi", Output: output})
+	logger := tc.MustMake(contract.LogKey).(contract.Log)
+
+	// panic 级别的日志写入所有的输出之后只 panic 一次
+	panics := 0
+	func() {
+		defer func() {
+			if recover() != nil {
+				panics++
+			}
+		}()
+		logger.Panic(context.Background(), "panic-line", nil)
+	}()
+	if panics != 1 {
+		t.Fatal("multi log should panic once")
+	}
+	if !output.Contains("panic-line") {
+		t.Fatalf("unexpected custom sink lines: %v", output.Lines())
+	}
+	content, err := ioutil.ReadFile(filepath.Join(folder, "panic.log"))
+	if err != nil || !strings.Contains(string(content), "panic-line") {
+		t.Fatalf("unexpected single sink content: %q, %v", content, err)
+	}
+}
+
+func TestHadeLogServiceProvider_MultiWatchConfig(t *testing.T) {
+	config := hadetest.NewMemoryConfig(map[string]interface{}{
+		"log": map[string]interface{}{
+			"level": "debug",
+			"sinks": []interface{}{map[string]interface{}{"driver": "custom", "level": "error"}},
+		},
+	})
+	tc := hadetest.NewTestContainer(t)
+	tc.Override(contract.ConfigKey, config)
+	output := &hadetest.LogCapture{}
+	_ = tc.Bind(&log.HadeLogServiceProvider{Driver: "multi", Output: output})
+	logger := tc.MustMake(contract.LogKey).(contract.Log)
+
+	// 修改输出的 level 和 formatter 之后马上生效
+	logger.Info(context.Background(), "before", nil)
+	err := config.Set("log.sinks", []interface{}{map[string]interface{}{"driver": "custom", "level": "info", "formatter": "json"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Info(context.Background(), "after", nil)
+	if err := logger.(framework.Shutdowner).Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	lines := output.Lines()
+	if len(lines) != 1 || !strings.Contains(lines[0], `"msg":"after"`) {
+		t.Fatalf("sink settings not reloaded: %v", lines)
+	}
+}
+
+func TestHadeLog_IsLevelEnable(t *testing.T) {
+	config := hadetest.NewMemoryConfig(map[string]interface{}{
+		"log": map[string]interface{}{"level": "warn"},
+	})
+	tc := hadetest.NewTestContainer(t)
+	tc.Override(contract.ConfigKey, config)
+	output := &hadetest.LogCapture{}
+	_ = tc.Bind(&log.HadeLogServiceProvider{Driver: "custom", Output: output})
+
+	// 只有不低于配置级别的日志才会输出
+	logger := tc.MustMake(contract.LogKey).(contract.Log)
+	logger.Info(context.Background(), "info", map[string]interface{}{})
+	logger.Warn(context.Background(), "warn", map[string]interface{}{})
+	logger.Error(context.Background(), "error", map[string]interface{}{})
+	if output.Contains("info") || !output.Contains("warn") || !output.Contains("error") {
+		t.Fatalf("unexpected output: %v", output.Lines())
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// OverflowBlock 缓冲区满了之后阻塞写入日志的 goroutine，直到有空间
+	OverflowBlock = "block"
+	// OverflowDropOldest 缓冲区满了之后丢弃最早的一行日志
+	OverflowDropOldest = "drop_oldest"
+	// OverflowDropNew 缓冲区满了之后丢弃新写入的日志
+	OverflowDropNew = "drop_new"
+)
+
+// AsyncOptions 异步写入日志的设置
+type AsyncOptions struct {
+	Size     int    // 缓冲区可以保存的日志行数，默认为 1024
+	Batch    int    // 每次最多合并写入的日志行数，默认为 128
+	Overflow string // 缓冲区满了之后的处理方式，默认为 block
+}
+
+// AsyncWriter 将日志保存在环形缓冲区中，由后台的 goroutine 合并之后批量写入 out
+type AsyncWriter struct {
+	out      io.Writer
+	batch    int
+	overflow string
+
+	lock     sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	ring     [][]byte
+	head     int  // 最早的一行日志在 ring 中的位置
+	size     int  // 缓冲区中的日志行数
+	writing  bool // 后台 goroutine 是否正在写入取出的日志
+	closed   bool
+	done     chan struct{}
+
+	dropped uint64
+}
+
+// NewAsyncWriter 创建异步写入 out 的 Writer，并且启动后台写入的 goroutine
+func NewAsyncWriter(out io.Writer, opts AsyncOptions) *AsyncWriter {
+	if opts.Size <= 0 {
+		opts.Size = 1024
+	}
+	if opts.Batch <= 0 {
+		opts.Batch = 128
+	}
+	if opts.Overflow == "" {
+		opts.Overflow = OverflowBlock
+	}
+	w := &AsyncWriter{
+		out:      out,
+		batch:    opts.Batch,
+		overflow: opts.Overflow,
+		ring:     make([][]byte, opts.Size),
+		done:     make(chan struct{}),
+	}
+	w.notEmpty = sync.NewCond(&w.lock)
+	w.notFull = sync.NewCond(&w.lock)
+	go w.run()
+	return w
+}
+
+// reopen 使用同样的设置创建写入 out 的 AsyncWriter，丢弃的日志行数继续累加
+func (w *AsyncWriter) reopen(out io.Writer) *AsyncWriter {
+	n := NewAsyncWriter(out, AsyncOptions{Size: len(w.ring), Batch: w.batch, Overflow: w.overflow})
+	n.dropped = w.Dropped()
+	return n
+}
+
+// Write 将一行日志放入缓冲区，关闭之后等待缓冲区中的日志写完，再直接写入 out
+func (w *AsyncWriter) Write(p []byte) (int, error) {
+	line := make([]byte, len(p))
+	copy(line, p)
+
+	w.lock.Lock()
+	for !w.closed && w.size == len(w.ring) {
+		switch w.overflow {
+		case OverflowDropNew:
+			w.lock.Unlock()
+			atomic.AddUint64(&w.dropped, 1)
+			return len(p), nil
+		case OverflowDropOldest:
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+			w.size--
+			atomic.AddUint64(&w.dropped, 1)
+		default:
+			w.notFull.Wait()
+		}
+	}
+	if w.closed {
+		w.lock.Unlock()
+		// 先写入的日志还在缓冲区中的时候直接写入会打乱顺序，也会和后台 goroutine 同时写入 out
+		<-w.done
+		w.lock.Lock()
+		defer w.lock.Unlock()
+		return w.out.Write(line)
+	}
+	w.ring[(w.head+w.size)%len(w.ring)] = line
+	w.size++
+	w.notEmpty.Signal()
+	w.lock.Unlock()
+	return len(p), nil
+}
+
+// Dropped 返回缓冲区满了之后丢弃的日志行数
+func (w *AsyncWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&w.dropped)
+}
+
+// Flush 等待缓冲区中的日志都写入 out，ctx 超时的时候返回 ctx 的错误
+func (w *AsyncWriter) Flush(ctx context.Context) error {
+	idle := make(chan struct{})
+	go func() {
+		w.lock.Lock()
+		for w.size > 0 || w.writing {
+			w.notFull.Wait()
+		}
+		w.lock.Unlock()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close 写入缓冲区中剩余的日志并且停止后台的 goroutine，之后的日志直接写入 out
+func (w *AsyncWriter) Close(ctx context.Context) error {
+	w.lock.Lock()
+	if !w.closed {
+		w.closed = true
+		w.notEmpty.Broadcast()
+		w.notFull.Broadcast()
+	}
+	w.lock.Unlock()
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 每次从缓冲区中取出最多 batch 行日志，合并之后写入 out
+func (w *AsyncWriter) run() {
+	defer close(w.done)
+	var buf bytes.Buffer
+	for {
+		w.lock.Lock()
+		for w.size == 0 && !w.closed {
+			w.notEmpty.Wait()
+		}
+		if w.size == 0 {
+			w.lock.Unlock()
+			return
+		}
+		buf.Reset()
+		for n := 0; n < w.batch && w.size > 0; n++ {
+			buf.Write(w.ring[w.head])
+			w.ring[w.head] = nil
+			w.head = (w.head + 1) % len(w.ring)
+			w.size--
+		}
+		w.writing = true
+		w.notFull.Broadcast()
+		w.lock.Unlock()
+
+		_, _ = w.out.Write(buf.Bytes())
+
+		w.lock.Lock()
+		w.writing = false
+		w.notFull.Broadcast()
+		w.lock.Unlock()
+	}
+}
@@ -2,8 +2,10 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"io"
 	pkgLog "log"
+	"sync"
 	"time"
 
 	"github.com/yefangyong/go-frame/framework"
@@ -11,13 +13,37 @@ import (
 	"github.com/yefangyong/go-frame/framework/provider/log/formatter"
 )
 
-// 日志的通用实例
+// panicFlushTimeout multi 驱动在 panic 之前等待每个输出写入的最长时间
+const panicFlushTimeout = time.Second
+
+// 日志的通用实例，With 返回的子日志和上级日志共用级别、格式、输出等设置
 type HadeLog struct {
 	container  framework.Container
 	level      contract.LogLevel
 	ctxFielder contract.CtxFielder
 	formatter  contract.Formatter
 	output     io.Writer
+	async      *AsyncWriter // 开启异步写入之后包装 output 的 Writer
+
+	// lock 保护 level 和 formatter，配置热更新的时候会在其他 goroutine 中修改它们
+	lock sync.RWMutex
+
+	parent *HadeLog               // 子日志的上级日志，根日志为 nil
+	fields map[string]interface{} // 子日志绑定的字段，创建之后不再修改
+	sinks  []*HadeLog             // multi 驱动的每个输出，有输出的时候不使用 output
+}
+
+// base 返回日志驱动中的 HadeLog，multi 驱动通过它获取每个输出
+func (h *HadeLog) base() *HadeLog {
+	return h
+}
+
+// root 返回保存设置的根日志
+func (h *HadeLog) root() *HadeLog {
+	if h.parent != nil {
+		return h.parent
+	}
+	return h
 }
 
 func (h *HadeLog) logf(level contract.LogLevel, ctx context.Context, msg string, field map[string]interface{}) error {
@@ -25,20 +51,60 @@ func (h *HadeLog) logf(level contract.LogLevel, ctx context.Context, msg string,
 	if !h.IsLevelEnable(level) {
 		return nil
 	}
-	fs := field
+	r := h.root()
+
+	// 字段的优先级从低到高为：绑定的字段、context 中的信息、调用时传入的字段，不修改调用方的 map
+	fs := make(map[string]interface{}, len(h.fields)+len(field))
+	for k, v := range h.fields {
+		fs[k] = v
+	}
 	// 使用 ctxFielder 获取 context 中的信息
-	if h.ctxFielder != nil {
-		t := h.ctxFielder(ctx)
-		for k, v := range t {
+	if r.ctxFielder != nil {
+		for k, v := range r.ctxFielder(ctx) {
 			fs[k] = v
 		}
 	}
+	for k, v := range field {
+		fs[k] = v
+	}
 
-	// 将日志信息根据 formatter 格式化为字符串
-	if h.formatter == nil {
-		h.formatter = formatter.TextFormatter
+	return r.write(level, msg, fs)
+}
+
+// write 格式化并输出一条日志，multi 驱动的根日志将日志分发给每个级别允许的输出
+func (h *HadeLog) write(level contract.LogLevel, msg string, fs map[string]interface{}) error {
+	if len(h.sinks) > 0 {
+		for _, sink := range h.sinks {
+			if !sink.IsLevelEnable(level) {
+				continue
+			}
+			// formatter 可能会修改字段，每个输出使用单独的 map
+			sfs := make(map[string]interface{}, len(fs))
+			for k, v := range fs {
+				sfs[k] = v
+			}
+			// panic 级别的日志也写入每个输出，所有输出都写完之后再 panic 一次
+			if ct, err := sink.format(level, msg, sfs); err == nil {
+				sink.writeLine(ct)
+			}
+		}
+		if level == contract.PanicLevel {
+			// 输出是异步写入的，panic 之前等待这条日志写入，panic 没有被恢复的时候也不会丢失
+			ctx, cancel := context.WithTimeout(context.Background(), panicFlushTimeout)
+			for _, sink := range h.sinks {
+				sink.flush(ctx)
+			}
+			cancel()
+			ct, err := h.format(level, msg, fs)
+			if err != nil {
+				return err
+			}
+			pkgLog.Panicln(string(ct))
+		}
+		return nil
 	}
-	ct, err := h.formatter(level, time.Now(), msg, field)
+
+	ct, err := h.format(level, msg, fs)
 	if err != nil {
 		return err
 	}
@@ -48,13 +114,65 @@ func (h *HadeLog) logf(level contract.LogLevel, ctx context.Context, msg string,
 		pkgLog.Panicln(string(ct))
 		return nil
 	}
-
-	// 通过 output 进行输出
-	_, _ = h.output.Write(ct)
-	_, _ = h.output.Write([]byte("\r\n"))
+	h.writeLine(ct)
 	return nil
 }
 
+// flush 等待异步缓冲区中的日志写入 output，没有开启异步写入的时候直接返回
+func (h *HadeLog) flush(ctx context.Context) {
+	h.lock.RLock()
+	async := h.async
+	h.lock.RUnlock()
+	if async != nil {
+		_ = async.Flush(ctx)
+	}
+}
+
+// format 将日志信息根据 formatter 格式化为字符串
+func (h *HadeLog) format(level contract.LogLevel, msg string, fs map[string]interface{}) ([]byte, error) {
+	h.lock.RLock()
+	format := h.formatter
+	h.lock.RUnlock()
+	if format == nil {
+		format = formatter.TextFormatter
+	}
+	return format(level, time.Now(), msg, fs)
+}
+
+// writeLine 通过 output 进行输出，换行和日志内容一次写入，避免并发的时候和其他日志交错
+func (h *HadeLog) writeLine(ct []byte) {
+	h.lock.RLock()
+	output := h.output
+	h.lock.RUnlock()
+	_, _ = output.Write(append(ct, '\r', '\n'))
+}
+
+// With 返回绑定了 fields 的子日志，子日志的字段是上级日志的字段和 fields 合并的结果，不影响上级日志
+func (h *HadeLog) With(fields map[string]interface{}) contract.Log {
+	merged := make(map[string]interface{}, len(h.fields)+len(fields))
+	for k, v := range h.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return &HadeLog{parent: h.root(), fields: merged}
+}
+
+// keyValues 将 k1, v1, k2, v2 格式的参数转换为字段，缺少值的 key 对应的值为 (MISSING)
+func keyValues(kvs []interface{}) map[string]interface{} {
+	fields := make(map[string]interface{}, (len(kvs)+1)/2)
+	for i := 0; i < len(kvs); i += 2 {
+		key := fmt.Sprint(kvs[i])
+		if i+1 < len(kvs) {
+			fields[key] = kvs[i+1]
+		} else {
+			fields[key] = "(MISSING)"
+		}
+	}
+	return fields
+}
+
 func (h *HadeLog) Panic(ctx context.Context, msg string, fields map[string]interface{}) {
 	h.logf(contract.PanicLevel, ctx, msg, fields)
 }
@@ -83,23 +201,171 @@ func (h *HadeLog) Trace(ctx context.Context, msg string, fields map[string]inter
 	h.logf(contract.TraceLevel, ctx, msg, fields)
 }
 
+func (h *HadeLog) Panicf(ctx context.Context, format string, args ...interface{}) {
+	if h.IsLevelEnable(contract.PanicLevel) {
+		h.logf(contract.PanicLevel, ctx, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+func (h *HadeLog) Panicw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if h.IsLevelEnable(contract.PanicLevel) {
+		h.logf(contract.PanicLevel, ctx, msg, keyValues(keysAndValues))
+	}
+}
+
+func (h *HadeLog) Fatalf(ctx context.Context, format string, args ...interface{}) {
+	if h.IsLevelEnable(contract.FatalLevel) {
+		h.logf(contract.FatalLevel, ctx, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+func (h *HadeLog) Fatalw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if h.IsLevelEnable(contract.FatalLevel) {
+		h.logf(contract.FatalLevel, ctx, msg, keyValues(keysAndValues))
+	}
+}
+
+func (h *HadeLog) Errorf(ctx context.Context, format string, args ...interface{}) {
+	if h.IsLevelEnable(contract.ErrorLevel) {
+		h.logf(contract.ErrorLevel, ctx, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+func (h *HadeLog) Errorw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if h.IsLevelEnable(contract.ErrorLevel) {
+		h.logf(contract.ErrorLevel, ctx, msg, keyValues(keysAndValues))
+	}
+}
+
+func (h *HadeLog) Warnf(ctx context.Context, format string, args ...interface{}) {
+	if h.IsLevelEnable(contract.WarnLevel) {
+		h.logf(contract.WarnLevel, ctx, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+func (h *HadeLog) Warnw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if h.IsLevelEnable(contract.WarnLevel) {
+		h.logf(contract.WarnLevel, ctx, msg, keyValues(keysAndValues))
+	}
+}
+
+func (h *HadeLog) Infof(ctx context.Context, format string, args ...interface{}) {
+	if h.IsLevelEnable(contract.InfoLevel) {
+		h.logf(contract.InfoLevel, ctx, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+func (h *HadeLog) Infow(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if h.IsLevelEnable(contract.InfoLevel) {
+		h.logf(contract.InfoLevel, ctx, msg, keyValues(keysAndValues))
+	}
+}
+
+func (h *HadeLog) Debugf(ctx context.Context, format string, args ...interface{}) {
+	if h.IsLevelEnable(contract.DebugLevel) {
+		h.logf(contract.DebugLevel, ctx, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+func (h *HadeLog) Debugw(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if h.IsLevelEnable(contract.DebugLevel) {
+		h.logf(contract.DebugLevel, ctx, msg, keyValues(keysAndValues))
+	}
+}
+
+func (h *HadeLog) Tracef(ctx context.Context, format string, args ...interface{}) {
+	if h.IsLevelEnable(contract.TraceLevel) {
+		h.logf(contract.TraceLevel, ctx, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+func (h *HadeLog) Tracew(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	if h.IsLevelEnable(contract.TraceLevel) {
+		h.logf(contract.TraceLevel, ctx, msg, keyValues(keysAndValues))
+	}
+}
+
+// SetLevel 设置日志级别，在子日志中调用的时候修改的是根日志的设置，下面的其他设置也是一样
 func (h *HadeLog) SetLevel(level contract.LogLevel) {
-	h.level = level
+	r := h.root()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.level = level
 }
 
 func (h *HadeLog) SetCtxFielder(handler contract.CtxFielder) {
-	h.ctxFielder = handler
+	h.root().ctxFielder = handler
 }
 
 func (h *HadeLog) SetFormatter(formatter contract.Formatter) {
-	h.formatter = formatter
+	r := h.root()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.formatter = formatter
 }
 
+// SetOutput 设置日志的输出，开启了异步写入的时候使用同样的设置包装新的 output
+// 原来缓冲区中的日志写入原来的 output 之后才返回
 func (h *HadeLog) SetOutput(out io.Writer) {
-	h.output = out
+	r := h.root()
+	r.lock.Lock()
+	old := r.async
+	r.async = nil
+	if old != nil && out != nil {
+		r.async = old.reopen(out)
+		out = r.async
+	}
+	r.output = out
+	r.lock.Unlock()
+	if old != nil {
+		_ = old.Close(context.Background())
+	}
+}
+
+// EnableAsync 将日志先写入缓冲区，再由后台的 goroutine 批量写入当前的 output
+// 之后调用 SetOutput 设置的 output 也会异步写入
+func (h *HadeLog) EnableAsync(opts AsyncOptions) {
+	r := h.root()
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.output == nil || r.async != nil {
+		return
+	}
+	r.async = NewAsyncWriter(r.output, opts)
+	r.output = r.async
+}
+
+// Dropped 返回异步写入的缓冲区满了之后丢弃的日志行数
+func (h *HadeLog) Dropped() uint64 {
+	r := h.root()
+	var dropped uint64
+	for _, sink := range r.sinks {
+		dropped += sink.Dropped()
+	}
+	r.lock.RLock()
+	async := r.async
+	r.lock.RUnlock()
+	if async != nil {
+		dropped += async.Dropped()
+	}
+	return dropped
+}
+
+// Shutdown 写入异步缓冲区中剩余的日志
+func (h *HadeLog) Shutdown(ctx context.Context) error {
+	h.lock.RLock()
+	async := h.async
+	h.lock.RUnlock()
+	if async == nil {
+		return nil
+	}
+	return async.Close(ctx)
 }
 
 // 判断这个日志级别是否可以打印
 func (h *HadeLog) IsLevelEnable(level contract.LogLevel) bool {
-	return h.level <= level
+	r := h.root()
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return level <= r.level
 }
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+// HadeMultiLog 将日志分发给多个输出，每个输出是一个单独的日志驱动，有自己的日志级别、输出格式和设置
+type HadeMultiLog struct {
+	HadeLog
+	drivers []interface{} // 每个输出对应的日志驱动实例
+}
+
+// NewHadeMultiLog 第六个参数为每个输出的日志驱动实例，比如 NewHadeRotateLog 创建的实例
+// 日志先经过当前日志的级别判断，再分发给每个级别允许的输出
+func NewHadeMultiLog(params ...interface{}) (interface{}, error) {
+	container := params[0].(framework.Container)
+	level := params[1].(contract.LogLevel)
+	ctxFielder := params[2].(contract.CtxFielder)
+	formatter := params[3].(contract.Formatter)
+	drivers := params[5].([]interface{})
+
+	log := &HadeMultiLog{drivers: drivers}
+	log.container = container
+	log.SetLevel(level)
+	log.SetCtxFielder(ctxFielder)
+	log.SetFormatter(formatter)
+	for _, driver := range drivers {
+		sink, ok := driver.(interface{ base() *HadeLog })
+		if !ok {
+			return nil, errors.New("multi log sink should be a hade log driver")
+		}
+		log.sinks = append(log.sinks, sink.base())
+	}
+	return log, nil
+}
+
+// Sinks 返回每个输出对应的日志驱动实例，顺序和 log.sinks 中的顺序相同
+func (l *HadeMultiLog) Sinks() []contract.Log {
+	sinks := make([]contract.Log, 0, len(l.drivers))
+	for _, driver := range l.drivers {
+		if sink, ok := driver.(contract.Log); ok {
+			sinks = append(sinks, sink)
+		}
+	}
+	return sinks
+}
+
+// Shutdown 关闭每个输出，写入异步缓冲区中剩余的日志并关闭日志文件
+func (l *HadeMultiLog) Shutdown(ctx context.Context) error {
+	var errs []string
+	if err := l.HadeLog.Shutdown(ctx); err != nil {
+		errs = append(errs, err.Error())
+	}
+	for _, driver := range l.drivers {
+		if s, ok := driver.(framework.Shutdowner); ok {
+			if err := s.Shutdown(ctx); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -19,6 +20,8 @@ type HadeRotateLog struct {
 	folder string
 	// 日志文件名
 	file string
+	// 日志切割的写入器
+	writer *rotatelogs.RotateLogs
 }
 
 func NewHadeRotateLog(params ...interface{}) (interface{}, error) {
@@ -29,11 +32,12 @@ func NewHadeRotateLog(params ...interface{}) (interface{}, error) {
 
 	appService := container.MustMake(contract.AppKey).(contract.App)
 	configService := container.MustMake(contract.ConfigKey).(contract.Config)
+	settings := driverSettings(configService, params)
 
 	// 从配置文件中获取folder信息，否则使用默认的LogFolder文件夹
 	folder := appService.LogFolder()
-	if configService.IsExist("log.folder") {
-		folder = configService.GetString("log.folder")
+	if settings.IsExist("folder") {
+		folder = settings.GetString("folder")
 	}
 	// 如果folder不存在，则创建
 	if !util.Exists(folder) {
@@ -42,41 +46,41 @@ func NewHadeRotateLog(params ...interface{}) (interface{}, error) {
 
 	// 从配置文件中获取file信息，否则使用默认的hade.log
 	file := "hade.log"
-	if configService.IsExist("log.file") {
-		file = configService.GetString("log.file")
+	if settings.IsExist("file") {
+		file = settings.GetString("file")
 	}
 
 	// 从配置文件获取date_format信息
 	dateFormat := "%Y%m%d%H"
-	if configService.IsExist("log.date_format") {
-		dateFormat = configService.GetString("log.date_format")
+	if settings.IsExist("date_format") {
+		dateFormat = settings.GetString("date_format")
 	}
 
 	linkName := rotatelogs.WithLinkName(filepath.Join(folder, file))
 	options := []rotatelogs.Option{linkName}
 
 	// 从配置文件获取rotate_count信息
-	if configService.IsExist("log.rotate_count") {
-		rotateCount := configService.GetInt("log.rotate_count")
+	if settings.IsExist("rotate_count") {
+		rotateCount := settings.GetInt("rotate_count")
 		options = append(options, rotatelogs.WithRotationCount(uint(rotateCount)))
 	}
 
 	// 从配置文件获取rotate_size信息
-	if configService.IsExist("log.rotate_size") {
-		rotateSize := configService.GetInt("log.rotate_size")
+	if settings.IsExist("rotate_size") {
+		rotateSize := settings.GetInt("rotate_size")
 		options = append(options, rotatelogs.WithRotationSize(int64(rotateSize)))
 	}
 
 	// 从配置文件获取max_age信息
-	if configService.IsExist("log.max_age") {
-		if maxAgeParse, err := time.ParseDuration(configService.GetString("log.max_age")); err == nil {
+	if settings.IsExist("max_age") {
+		if maxAgeParse, err := time.ParseDuration(settings.GetString("max_age")); err == nil {
 			options = append(options, rotatelogs.WithMaxAge(maxAgeParse))
 		}
 	}
 
 	// 从配置文件获取rotate_time信息
-	if configService.IsExist("log.rotate_time") {
-		if rotateTimeParse, err := time.ParseDuration(configService.GetString("log.rotate_time")); err == nil {
+	if settings.IsExist("rotate_time") {
+		if rotateTimeParse, err := time.ParseDuration(settings.GetString("rotate_time")); err == nil {
 			options = append(options, rotatelogs.WithRotationTime(rotateTimeParse))
 		}
 	}
@@ -93,6 +97,18 @@ func NewHadeRotateLog(params ...interface{}) (interface{}, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "new rotatelogs error")
 	}
+	log.writer = w
 	log.SetOutput(w)
 	return log, nil
 }
+
+// Shutdown 写入异步缓冲区中剩余的日志，然后关闭当前正在写入的日志文件
+func (l *HadeRotateLog) Shutdown(ctx context.Context) error {
+	if err := l.HadeLog.Shutdown(ctx); err != nil {
+		return err
+	}
+	if l.writer == nil {
+		return nil
+	}
+	return l.writer.Close()
+}
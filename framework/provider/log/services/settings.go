@@ -0,0 +1,33 @@
+package services
+
+import (
+	"github.com/spf13/cast"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+// logSettings 日志驱动的设置，比如 folder、file、rotate_count 等
+type logSettings map[string]interface{}
+
+// driverSettings 返回日志驱动的设置，默认读取配置中的 log，multi 驱动中的每个输出通过第六个参数传入自己的设置
+func driverSettings(configService contract.Config, params []interface{}) logSettings {
+	if len(params) > 5 {
+		if settings, ok := params[5].(map[string]interface{}); ok {
+			return settings
+		}
+	}
+	return configService.GetStringMap("log")
+}
+
+func (o logSettings) IsExist(key string) bool {
+	val, ok := o[key]
+	return ok && val != nil
+}
+
+func (o logSettings) GetString(key string) string {
+	return cast.ToString(o[key])
+}
+
+func (o logSettings) GetInt(key string) int {
+	return cast.ToInt(o[key])
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 
@@ -32,10 +33,11 @@ func NewHadeSingleLog(params ...interface{}) (interface{}, error) {
 
 	appService := container.MustMake(contract.AppKey).(contract.App)
 	configService := container.MustMake(contract.ConfigKey).(contract.Config)
+	settings := driverSettings(configService, params)
 
 	folder := appService.LogFolder()
-	if configService.IsExist("log.folder") {
-		folder = configService.GetString("log.folder")
+	if settings.IsExist("folder") {
+		folder = settings.GetString("folder")
 	}
 	log.folder = folder
 	if !util.Exists(folder) {
@@ -43,8 +45,8 @@ func NewHadeSingleLog(params ...interface{}) (interface{}, error) {
 	}
 
 	log.file = "hade.log"
-	if configService.IsExist("log.file") {
-		log.file = configService.GetString("log.file")
+	if settings.IsExist("file") {
+		log.file = settings.GetString("file")
 	}
 
 	fd, err := os.OpenFile(filepath.Join(folder, log.file), os.O_APPEND|os.O_CREATE|os.O_RDWR, 0666)
@@ -52,6 +54,21 @@ func NewHadeSingleLog(params ...interface{}) (interface{}, error) {
 		return nil, errors.Wrap(err, "open log file err")
 	}
 
+	log.fd = fd
 	log.SetOutput(fd)
 	return log, nil
 }
+
+// Shutdown 写入异步缓冲区中剩余的日志，然后将日志刷新到磁盘并关闭日志文件
+func (l *HadeSingleLog) Shutdown(ctx context.Context) error {
+	if err := l.HadeLog.Shutdown(ctx); err != nil {
+		return err
+	}
+	if l.fd == nil {
+		return nil
+	}
+	if err := l.fd.Sync(); err != nil {
+		return err
+	}
+	return l.fd.Close()
+}
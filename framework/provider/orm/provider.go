@@ -3,8 +3,14 @@ package orm
 import (
 	"github.com/yefangyong/go-frame/framework"
 	"github.com/yefangyong/go-frame/framework/contract"
+	hadeConfig "github.com/yefangyong/go-frame/framework/provider/config"
 )
 
+// 每个数据库连接的配置，hade config validate 使用它检查 database 配置
+func init() {
+	hadeConfig.RegisterSchema("database.*", (*contract.DBConfig)(nil))
+}
+
 type GormProvider struct {
 }
 
@@ -27,3 +33,8 @@ func (g GormProvider) Params(container framework.Container) []interface{} {
 func (g GormProvider) Name() string {
 	return contract.ORMKEY
 }
+
+// Depends orm 服务需要读取数据库配置，并且使用日志服务记录sql
+func (g GormProvider) Depends() []string {
+	return []string{contract.ConfigKey, contract.LogKey}
+}
@@ -2,6 +2,7 @@ package orm
 
 import (
 	"context"
+	"database/sql"
 	"sync"
 	"time"
 
@@ -19,7 +20,8 @@ import (
 // HadeGorm 代表hade框架的orm实现
 type HadeGorm struct {
 	container framework.Container
-	dbs       map[string]*gorm.DB // key为dsn, value为gorm.DB（连接池）
+	dbs       map[string]*gorm.DB            // key为dsn, value为gorm.DB（连接池）
+	options   map[string][]contract.DBOption // key为dsn, value为创建连接池时使用的选项，配置变化的时候用来重新计算连接池配置
 	lock      *sync.RWMutex
 }
 
@@ -28,40 +30,26 @@ func NewHadeGorm(params ...interface{}) (interface{}, error) {
 	container := params[0].(framework.Container)
 	dbs := make(map[string]*gorm.DB)
 	lock := &sync.RWMutex{}
-	return &HadeGorm{
+	app := &HadeGorm{
 		container: container,
 		dbs:       dbs,
+		options:   map[string][]contract.DBOption{},
 		lock:      lock,
-	}, nil
+	}
+	// 数据库配置变化的时候调整已有连接池的大小
+	configService := container.MustMake(contract.ConfigKey).(contract.Config)
+	configService.Watch("database", func(oldVal, newVal interface{}) {
+		app.reloadPool()
+	})
+	return app, nil
 }
 
 // GetDB 获取DB实例
 func (app *HadeGorm) GetDB(option ...contract.DBOption) (*gorm.DB, error) {
 	logger := app.container.MustMake(contract.LogKey).(contract.Log)
-
-	// 读取默认的配置
-	config := GetBaseConfig(app.container)
-
-	// 设置Logger
-	OrmLogger := NewOrmLogger(logger)
-	config.Config = &gorm.Config{
-		Logger: OrmLogger,
-	}
-
-	// option 对opt进行修改
-	for _, opt := range option {
-		if err := opt(app.container, config); err != nil {
-			return nil, err
-		}
-	}
-
-	// 如果最终的config没有设置dsn，就生成dsn
-	if config.Dsn == "" {
-		dsn, err := config.FormatDsn()
-		if err != nil {
-			return nil, err
-		}
-		config.Dsn = dsn
+	config, err := app.buildConfig(logger, option)
+	if err != nil {
+		return nil, err
 	}
 
 	// 判断是否已经实例化gorm.DB
@@ -78,7 +66,6 @@ func (app *HadeGorm) GetDB(option ...contract.DBOption) (*gorm.DB, error) {
 
 	// 实例化gorm.DB
 	var db *gorm.DB
-	var err error
 	switch config.Driver {
 	case "mysql":
 		db, err = gorm.Open(mysql.Open(config.Dsn), config)
@@ -96,7 +83,45 @@ func (app *HadeGorm) GetDB(option ...contract.DBOption) (*gorm.DB, error) {
 	if err != nil {
 		return nil, err
 	}
+	setPool(sqlDB, config, logger)
+
+	app.dbs[config.Dsn] = db
+	app.options[config.Dsn] = option
+	return db, nil
+}
+
+// buildConfig 读取默认配置并且使用 option 修改，得到最终的数据库配置
+func (app *HadeGorm) buildConfig(logger contract.Log, option []contract.DBOption) (*contract.DBConfig, error) {
+	// 读取默认的配置
+	config := GetBaseConfig(app.container)
+
+	// 设置Logger
+	OrmLogger := NewOrmLogger(logger)
+	config.Config = &gorm.Config{
+		Logger: OrmLogger,
+	}
+
+	// option 对opt进行修改
+	for _, opt := range option {
+		if err := opt(app.container, config); err != nil {
+			return nil, err
+		}
+	}
+
+	// 如果最终的config没有设置dsn，就生成dsn
+	if config.Dsn == "" {
+		dsn, err := config.FormatDsn()
+		if err != nil {
+			return nil, err
+		}
+		config.Dsn = dsn
+	}
 
+	return config, nil
+}
+
+// setPool 根据配置设置连接池的大小和连接的生命周期
+func setPool(sqlDB *sql.DB, config *contract.DBConfig, logger contract.Log) {
 	if config.ConnMaxIdle > 0 {
 		sqlDB.SetMaxIdleConns(config.ConnMaxIdle)
 	}
@@ -115,7 +140,47 @@ func (app *HadeGorm) GetDB(option ...contract.DBOption) (*gorm.DB, error) {
 			sqlDB.SetConnMaxLifetime(liftTime)
 		}
 	}
+}
 
-	app.dbs[config.Dsn] = db
-	return db, nil
+// reloadPool 使用最新的配置重新设置已有连接池，连接地址变化的连接池需要重启之后才能生效
+func (app *HadeGorm) reloadPool() {
+	logger := app.container.MustMake(contract.LogKey).(contract.Log)
+	app.lock.RLock()
+	defer app.lock.RUnlock()
+	for dsn, db := range app.dbs {
+		config, err := app.buildConfig(logger, app.options[dsn])
+		if err != nil {
+			logger.Error(context.Background(), "reload database config error", map[string]interface{}{
+				"err": err.Error(),
+			})
+			continue
+		}
+		if config.Dsn != dsn {
+			logger.Warn(context.Background(), "database dsn changed, restart to take effect", map[string]interface{}{})
+			continue
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			continue
+		}
+		setPool(sqlDB, config, logger)
+	}
+}
+
+// Shutdown 关闭所有已经创建的数据库连接池
+func (app *HadeGorm) Shutdown(ctx context.Context) error {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	var lastErr error
+	for dsn, db := range app.dbs {
+		sqlDB, err := db.DB()
+		if err == nil {
+			err = sqlDB.Close()
+		}
+		if err != nil {
+			lastErr = err
+		}
+		delete(app.dbs, dsn)
+	}
+	return lastErr
 }
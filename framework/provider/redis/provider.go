@@ -27,3 +27,8 @@ func (r RedisProvider) Params(container framework.Container) []interface{} {
 func (r RedisProvider) Name() string {
 	return contract.RedisKey
 }
+
+// Depends redis 服务需要读取 redis 配置，并且使用日志服务记录错误
+func (r RedisProvider) Depends() []string {
+	return []string{contract.ConfigKey, contract.LogKey}
+}
@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"sync"
 
 	"github.com/go-redis/redis/v8"
@@ -57,3 +58,17 @@ func (app *HadeRedisService) GetClient(options ...contract.RedisOption) (*redis.
 	app.clients[key] = client
 	return client, nil
 }
+
+// Shutdown 关闭所有已经创建的 redis 客户端
+func (app *HadeRedisService) Shutdown(ctx context.Context) error {
+	app.lock.Lock()
+	defer app.lock.Unlock()
+	var lastErr error
+	for key, client := range app.clients {
+		if err := client.Close(); err != nil {
+			lastErr = err
+		}
+		delete(app.clients, key)
+	}
+	return lastErr
+}
@@ -0,0 +1,209 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+)
+
+// HadeScope 服务容器的子作用域，一般每个请求对应一个作用域
+// 作用域内的服务在作用域中只实例化一次，其他的服务直接从父容器中获取
+type HadeScope struct {
+	// parent 父容器
+	parent *HadeContainer
+	// providers 只在当前作用域内绑定的服务提供者
+	providers map[string]ServiceProvider
+	// instances 当前作用域内实例化的服务
+	instances map[string]interface{}
+	// booted 记录作用域内服务的实例化顺序，释放的时候按照相反的顺序进行
+	booted []string
+	// building 存储作用域内正在实例化的服务，和父容器一样同一个服务同时只有一个 goroutine 进行实例化
+	building map[string]*instanceCall
+	// versions 记录每个服务凭证在作用域内被绑定的次数，用于丢弃服务提供者被替换之前开始的实例化结果
+	versions map[string]int
+	lock     sync.Mutex
+}
+
+// Bind 在当前作用域内绑定一个服务提供者，它只在当前作用域内生效，并且总是在第一次 Make 的时候实例化
+func (s *HadeScope) Bind(provider ServiceProvider) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.providers == nil {
+		s.providers = map[string]ServiceProvider{}
+	}
+	if s.versions == nil {
+		s.versions = map[string]int{}
+	}
+	key := provider.Name()
+	s.providers[key] = provider
+	s.versions[key]++
+	delete(s.instances, key)
+	return nil
+}
+
+// IsBind 关键字凭证是否在当前作用域或者父容器中绑定
+func (s *HadeScope) IsBind(key string) bool {
+	s.lock.Lock()
+	_, ok := s.providers[key]
+	s.lock.Unlock()
+	return ok || s.parent.IsBind(key)
+}
+
+func (s *HadeScope) Make(key string) (interface{}, error) {
+	return s.make(key, nil, false, nil)
+}
+
+func (s *HadeScope) MustMake(key string) interface{} {
+	ins, err := s.make(key, nil, false, nil)
+	if err != nil {
+		panic(err)
+	}
+	return ins
+}
+
+func (s *HadeScope) MakeNew(key string, params []interface{}) (interface{}, error) {
+	return s.make(key, params, true, nil)
+}
+
+func (s *HadeScope) makeFrom(call *instanceCall, key string, params []interface{}, forceNew bool) (interface{}, error) {
+	return s.make(key, params, forceNew, call)
+}
+
+// taggedKeys 标签都注册在父容器中
+func (s *HadeScope) taggedKeys(tag string) []string {
+	return s.parent.taggedKeys(tag)
+}
+
+// MakeTagged 获取父容器中某个标签下的所有服务，作用域内的服务在当前作用域中实例化
+func (s *HadeScope) MakeTagged(tag string) ([]interface{}, error) {
+	return makeTagged(s, s.taggedKeys(tag))
+}
+
+func (s *HadeScope) Invoke(fn interface{}) error {
+	return invoke(s, fn)
+}
+
+func (s *HadeScope) Fill(target interface{}) error {
+	return fill(s, target)
+}
+
+// findProvider 查找需要在当前作用域实例化的服务提供者
+func (s *HadeScope) findProvider(key string) ServiceProvider {
+	s.lock.Lock()
+	sp, ok := s.providers[key]
+	s.lock.Unlock()
+	if ok {
+		return sp
+	}
+	return s.parent.scopedProvider(key)
+}
+
+// make 在作用域中获取服务，parent 为正在进行的实例化，和父容器中的 make 相同
+func (s *HadeScope) make(key string, params []interface{}, forceNew bool, parent *instanceCall) (interface{}, error) {
+	parent = activeCall(parent)
+	sp := s.findProvider(key)
+	if sp != nil {
+		// 作用域内的服务以服务提供者的凭证作为实例的 key，别名获取到的是同一个实例
+		key = sp.Name()
+	}
+	if sp == nil {
+		// 不是作用域内的服务，直接从父容器中获取
+		return s.parent.make(key, params, forceNew, "", parent)
+	}
+
+	if forceNew {
+		if err := s.makeDepends(key, sp, parent); err != nil {
+			return nil, err
+		}
+		return buildInstance(withCall(s, parent), sp, params, nil)
+	}
+
+	s.lock.Lock()
+	if ins, ok := s.instances[key]; ok {
+		s.lock.Unlock()
+		return ins, nil
+	}
+	if call, ok := s.building[key]; ok {
+		s.lock.Unlock()
+		// 已经有 goroutine 在实例化这个服务，等待它的结果，保证作用域内只有一个实例
+		if err := waitCall(parent, call); err != nil {
+			return nil, err
+		}
+		return call.ins, call.err
+	}
+	if s.building == nil {
+		s.building = map[string]*instanceCall{}
+	}
+	call := &instanceCall{done: make(chan struct{}), key: key, parent: parent, version: s.versions[key]}
+	s.building[key] = call
+	beginCall(parent, call)
+	s.lock.Unlock()
+
+	completed := false
+	defer func() {
+		// 服务提供者在实例化过程中 panic，通知等待的 goroutine 实例化失败，panic 继续向上传递
+		if !completed {
+			call.ins, call.err = nil, errors.New("contract "+key+" panicked during instantiation")
+		}
+		s.lock.Lock()
+		// 实例化过程中服务提供者被替换了，这次实例化的结果不再保存
+		stale := call.err == nil && s.versions[key] != call.version
+		if call.err == nil && !stale {
+			if s.instances == nil {
+				s.instances = map[string]interface{}{}
+			}
+			s.instances[key] = call.ins
+			s.booted = append(s.booted, key)
+		}
+		delete(s.building, key)
+		s.lock.Unlock()
+		endCall(parent)
+		close(call.done)
+		// 丢弃的实例不会再被关闭，这里释放它持有的资源
+		if stale {
+			shutdownInstance(context.Background(), key, call.ins, sp)
+		}
+	}()
+
+	// 实例化的时候不持有锁，服务在实例化过程中可以继续从作用域中获取其他服务
+	if call.err = s.makeDepends(key, sp, call); call.err == nil {
+		call.ins, call.err = buildInstance(withCall(s, call), sp, nil, nil)
+	}
+	completed = true
+	return call.ins, call.err
+}
+
+// makeDepends 在作用域中实例化 key 依赖的服务，call 为正在进行的实例化
+func (s *HadeScope) makeDepends(key string, sp ServiceProvider, call *instanceCall) error {
+	for _, dep := range providerDepends(sp) {
+		if _, err := s.make(dep, nil, false, call); err != nil {
+			return errors.New("contract " + key + " depends on " + dep + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+// Shutdown 按照实例化的相反顺序释放作用域内的服务，父容器中的服务不受影响
+// 服务实例和服务提供者实现了 Shutdowner 接口才会被调用
+func (s *HadeScope) Shutdown(ctx context.Context) error {
+	s.lock.Lock()
+	booted, instances := s.booted, s.instances
+	s.booted, s.instances = nil, nil
+	s.lock.Unlock()
+
+	var errs []string
+	for i := len(booted) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, "shutdown aborted: "+err.Error())
+			break
+		}
+		// 和父容器一样，服务实例和服务提供者实现了 Shutdowner 接口都会被调用
+		key := booted[i]
+		errs = append(errs, shutdownInstance(ctx, key, instances[key], s.findProvider(key))...)
+	}
+	if len(errs) > 0 {
+		return errors.New("scope shutdown error: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package testing
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/config"
+)
+
+// MemoryConfig 内存中的配置服务，第一层的 key 相当于配置文件的文件名
+type MemoryConfig struct {
+	lock     sync.RWMutex
+	maps     map[string]interface{}
+	watchers []*memoryWatcher
+}
+
+type memoryWatcher struct {
+	key string
+	fn  contract.ConfigWatcher
+}
+
+// NewMemoryConfig 使用 maps 初始化配置，比如 {"app": {"address": ":8080"}} 对应 app.address
+func NewMemoryConfig(maps map[string]interface{}) *MemoryConfig {
+	if maps == nil {
+		maps = map[string]interface{}{}
+	}
+	return &MemoryConfig{maps: maps}
+}
+
+// find 通过点分隔的路径查找配置项
+func (m *MemoryConfig) find(key string) interface{} {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.search(key)
+}
+
+func (m *MemoryConfig) search(key string) interface{} {
+	var cur interface{} = m.maps
+	for _, p := range strings.Split(key, ".") {
+		next, err := cast.ToStringMapE(cur)
+		if err != nil {
+			return nil
+		}
+		if cur = next[p]; cur == nil {
+			return nil
+		}
+	}
+	return cur
+}
+
+func (m *MemoryConfig) IsExist(key string) bool {
+	return m.find(key) != nil
+}
+
+func (m *MemoryConfig) Get(key string) interface{} {
+	return m.find(key)
+}
+
+func (m *MemoryConfig) GetString(key string) string {
+	return cast.ToString(m.find(key))
+}
+
+func (m *MemoryConfig) GetInt(key string) int {
+	return cast.ToInt(m.find(key))
+}
+
+func (m *MemoryConfig) GetBool(key string) bool {
+	return cast.ToBool(m.find(key))
+}
+
+func (m *MemoryConfig) GetFloat64(key string) float64 {
+	return cast.ToFloat64(m.find(key))
+}
+
+func (m *MemoryConfig) GetTime(key string) time.Time {
+	return cast.ToTime(m.find(key))
+}
+
+func (m *MemoryConfig) GetStringSlice(key string) []string {
+	return cast.ToStringSlice(m.find(key))
+}
+
+func (m *MemoryConfig) GetIntSlice(key string) []int {
+	return cast.ToIntSlice(m.find(key))
+}
+
+func (m *MemoryConfig) GetStringMap(key string) map[string]interface{} {
+	return cast.ToStringMap(m.find(key))
+}
+
+func (m *MemoryConfig) GetStringMapString(key string) map[string]string {
+	return cast.ToStringMapString(m.find(key))
+}
+
+func (m *MemoryConfig) GetStringMapStringSlice(key string) map[string][]string {
+	return cast.ToStringMapStringSlice(m.find(key))
+}
+
+func (m *MemoryConfig) Load(key string, val interface{}) error {
+	return config.Decode(key, m.find(key), val, false)
+}
+
+func (m *MemoryConfig) Watch(key string, fn contract.ConfigWatcher) func() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	w := &memoryWatcher{key: key, fn: fn}
+	m.watchers = append(m.watchers, w)
+	return func() {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+		for i, exist := range m.watchers {
+			if exist == w {
+				m.watchers = append(m.watchers[:i:i], m.watchers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Set 修改 key 对应的配置，用于在测试中模拟配置的热更新，值有变化的订阅者会被同步调用
+func (m *MemoryConfig) Set(key string, val interface{}) error {
+	m.lock.Lock()
+	olds := make([]interface{}, len(m.watchers))
+	for i, w := range m.watchers {
+		olds[i] = copyValue(m.search(w.key))
+	}
+
+	cur := m.maps
+	path := strings.Split(key, ".")
+	for _, p := range path[:len(path)-1] {
+		next, ok := cur[p].(map[string]interface{})
+		if !ok {
+			next = map[string]interface{}{}
+			cur[p] = next
+		}
+		cur = next
+	}
+	cur[path[len(path)-1]] = val
+
+	type change struct {
+		fn             contract.ConfigWatcher
+		oldVal, newVal interface{}
+	}
+	var changes []change
+	for i, w := range m.watchers {
+		if newVal := m.search(w.key); !reflect.DeepEqual(olds[i], newVal) {
+			changes = append(changes, change{fn: w.fn, oldVal: olds[i], newVal: copyValue(newVal)})
+		}
+	}
+	m.lock.Unlock()
+
+	for _, c := range changes {
+		c.fn(c.oldVal, c.newVal)
+	}
+	return nil
+}
+
+// copyValue 深度复制配置中的 map，Set 会直接修改 map，通知订阅者的旧值需要是修改之前的副本
+func copyValue(val interface{}) interface{} {
+	maps, ok := val.(map[string]interface{})
+	if !ok {
+		return val
+	}
+	ret := make(map[string]interface{}, len(maps))
+	for k, v := range maps {
+		ret[k] = copyValue(v)
+	}
+	return ret
+}
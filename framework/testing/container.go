@@ -0,0 +1,114 @@
+// Package testing 提供基于服务容器的测试工具
+// 包括预先配置好的测试容器、服务替换、基于 httptest 的 gin.Engine 以及请求和响应的断言
+package testing
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/app"
+	"github.com/yefangyong/go-frame/framework/provider/cache"
+	"github.com/yefangyong/go-frame/framework/provider/log"
+	"github.com/yefangyong/go-frame/framework/provider/log/formatter"
+)
+
+// TestContainer 测试使用的服务容器
+// 默认绑定了 app、内存中的 env 和 config、收集输出的 log、以及内存缓存
+type TestContainer struct {
+	*framework.HadeContainer
+	t    testing.TB
+	logs *LogCapture
+}
+
+// Option 用于修改测试容器的初始化参数
+type Option func(o *options)
+
+type options struct {
+	config map[string]interface{}
+	env    map[string]string
+}
+
+// WithConfig 设置测试容器中的配置，第一层的 key 相当于配置文件的文件名
+func WithConfig(config map[string]interface{}) Option {
+	return func(o *options) {
+		o.config = config
+	}
+}
+
+// WithEnv 设置测试容器中的环境变量
+func WithEnv(env map[string]string) Option {
+	return func(o *options) {
+		o.env = env
+	}
+}
+
+// NewTestContainer 创建一个测试容器，测试结束之后会关闭容器中的服务并删除临时目录
+func NewTestContainer(t testing.TB, opts ...Option) *TestContainer {
+	t.Helper()
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	baseFolder, err := ioutil.TempDir("", "hade-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := &TestContainer{
+		HadeContainer: framework.NewHadeContainer(),
+		t:             t,
+		logs:          &LogCapture{},
+	}
+	t.Cleanup(func() {
+		tc.Shutdown(context.Background())
+		os.RemoveAll(baseFolder)
+	})
+
+	tc.mustBind(&app.HadeAppProvider{BaseFolder: baseFolder})
+	tc.mustBind(&instanceProvider{key: contract.EnvKey, instance: NewMemoryEnv(o.env)})
+	tc.mustBind(&instanceProvider{key: contract.ConfigKey, instance: NewMemoryConfig(o.config)})
+	tc.mustBind(&log.HadeLogServiceProvider{
+		Driver:    "custom",
+		Level:     contract.TraceLevel,
+		Formatter: formatter.TextFormatter,
+		Output:    tc.logs,
+	})
+	tc.mustBind(&cache.HadeCacheProvider{Driver: "memory"})
+	return tc
+}
+
+func (tc *TestContainer) mustBind(provider framework.ServiceProvider) {
+	tc.t.Helper()
+	if err := tc.Bind(provider); err != nil {
+		tc.t.Fatal(err)
+	}
+}
+
+// Logs 返回日志服务输出内容的收集器
+func (tc *TestContainer) Logs() *LogCapture {
+	return tc.logs
+}
+
+// Override 使用 instance 替换服务凭证 key 对应的服务，测试结束之后恢复为原来的服务提供者
+// 原来没有绑定的服务凭证在测试结束之后解除绑定
+func (tc *TestContainer) Override(key string, instance interface{}) {
+	tc.t.Helper()
+	old := tc.Provider(key)
+	tc.mustBind(&instanceProvider{key: key, instance: instance})
+	tc.t.Cleanup(func() {
+		var err error
+		if old != nil {
+			err = tc.Bind(old)
+		} else {
+			err = tc.Unbind(key)
+		}
+		if err != nil {
+			tc.t.Error(err)
+		}
+	})
+}
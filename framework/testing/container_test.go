@@ -0,0 +1,29 @@
+package testing
+
+import (
+	"testing"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+)
+
+func TestTestContainer_Override(t *testing.T) {
+	var tc *TestContainer
+	fake := NewMemoryConfig(map[string]interface{}{"app": map[string]interface{}{"name": "fake"}})
+	t.Run("override", func(t *testing.T) {
+		tc = NewTestContainer(t, WithConfig(map[string]interface{}{"app": map[string]interface{}{"name": "hade"}}))
+		tc.Override(contract.ConfigKey, fake)
+		tc.Override("test:fake", "fake")
+		if tc.MustMake(contract.ConfigKey) != fake || tc.MustMake("test:fake") != "fake" {
+			t.Fatal("Override should replace the service")
+		}
+	})
+
+	// 测试结束之后恢复原来的服务，原来没有绑定的服务凭证解除绑定
+	if tc.IsBind("test:fake") {
+		t.Fatal("key bound only by Override should be unbound after test")
+	}
+	config := tc.MustMake(contract.ConfigKey).(contract.Config)
+	if config == fake || config.GetString("app.name") != "hade" {
+		t.Fatal("overridden service should be restored after test")
+	}
+}
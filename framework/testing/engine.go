@@ -0,0 +1,232 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/yefangyong/go-frame/framework/gin"
+	"github.com/yefangyong/go-frame/framework/middleware"
+)
+
+// TestEngine 绑定了测试容器的 gin.Engine，请求通过 httptest 直接发送给 Engine
+type TestEngine struct {
+	*gin.Engine
+	t         testing.TB
+	container *TestContainer
+}
+
+// NewEngine 使用测试容器创建 gin.Engine，register 用于注册路由，和业务中的 Routes 一致
+func (tc *TestContainer) NewEngine(register func(r *gin.Engine) error) *TestEngine {
+	tc.t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.SetContainer(tc)
+	r.Use(middleware.RequestScope())
+	if register != nil {
+		if err := register(r); err != nil {
+			tc.t.Fatal(err)
+		}
+	}
+	return &TestEngine{Engine: r, t: tc.t, container: tc}
+}
+
+// Container 返回 Engine 使用的测试容器
+func (e *TestEngine) Container() *TestContainer {
+	return e.container
+}
+
+// GET 创建一个 GET 请求
+func (e *TestEngine) GET(path string) *Request {
+	return e.Request(http.MethodGet, path)
+}
+
+// POST 创建一个 POST 请求
+func (e *TestEngine) POST(path string) *Request {
+	return e.Request(http.MethodPost, path)
+}
+
+// Request 创建一个请求，调用 Do 之后发送给 Engine
+func (e *TestEngine) Request(method, path string) *Request {
+	return &Request{engine: e, method: method, path: path, header: http.Header{}, query: url.Values{}}
+}
+
+// Request 测试请求的构造器
+type Request struct {
+	engine *TestEngine
+	method string
+	path   string
+	header http.Header
+	query  url.Values
+	body   io.Reader
+}
+
+// WithHeader 设置请求头
+func (r *Request) WithHeader(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// WithQuery 增加查询参数
+func (r *Request) WithQuery(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// WithJSON 将 v 编码为 json 作为请求体
+func (r *Request) WithJSON(v interface{}) *Request {
+	r.engine.t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		r.engine.t.Fatal(err)
+	}
+	r.body = bytes.NewReader(data)
+	r.header.Set("Content-Type", "application/json")
+	return r
+}
+
+// WithBody 设置请求体
+func (r *Request) WithBody(body io.Reader) *Request {
+	r.body = body
+	return r
+}
+
+// Do 发送请求并返回响应
+func (r *Request) Do() *Response {
+	r.engine.t.Helper()
+	target := r.path
+	if len(r.query) > 0 {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + r.query.Encode()
+	}
+	req := httptest.NewRequest(r.method, target, r.body)
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	r.engine.ServeHTTP(w, req)
+	return &Response{ResponseRecorder: w, t: r.engine.t}
+}
+
+// Response 测试请求的响应，提供常用的断言方法
+type Response struct {
+	*httptest.ResponseRecorder
+	t testing.TB
+}
+
+// AssertStatus 断言响应状态码
+func (r *Response) AssertStatus(code int) *Response {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Errorf("status = %d, want %d, body: %s", r.Code, code, r.Body.String())
+	}
+	return r
+}
+
+// AssertBodyContains 断言响应体包含 s
+func (r *Response) AssertBodyContains(s string) *Response {
+	r.t.Helper()
+	if !strings.Contains(r.Body.String(), s) {
+		r.t.Errorf("body %q does not contain %q", r.Body.String(), s)
+	}
+	return r
+}
+
+// DecodeJSON 将响应体解析到 v 中
+func (r *Response) DecodeJSON(v interface{}) error {
+	return json.Unmarshal(r.Body.Bytes(), v)
+}
+
+// AssertJSON 断言响应体和 expected 编码成 json 之后的内容一致
+func (r *Response) AssertJSON(expected interface{}) *Response {
+	r.t.Helper()
+	var got, want interface{}
+	if err := r.DecodeJSON(&got); err != nil {
+		r.t.Errorf("decode body %q: %v", r.Body.String(), err)
+		return r
+	}
+	if err := normalizeJSON(expected, &want); err != nil {
+		r.t.Errorf("encode expected: %v", err)
+		return r
+	}
+	if !jsonEqual(got, want) {
+		r.t.Errorf("body = %s, want %s", r.Body.String(), mustJSON(want))
+	}
+	return r
+}
+
+// AssertJSONPath 断言响应体中 path 对应的值，path 使用 . 分隔，数组使用下标，比如 data.0.name
+func (r *Response) AssertJSONPath(path string, expected interface{}) *Response {
+	r.t.Helper()
+	var body, want interface{}
+	if err := r.DecodeJSON(&body); err != nil {
+		r.t.Errorf("decode body %q: %v", r.Body.String(), err)
+		return r
+	}
+	got, ok := lookupJSONPath(body, path)
+	if !ok {
+		r.t.Errorf("path %s not found in body %s", path, r.Body.String())
+		return r
+	}
+	if err := normalizeJSON(expected, &want); err != nil {
+		r.t.Errorf("encode expected: %v", err)
+		return r
+	}
+	if !jsonEqual(got, want) {
+		r.t.Errorf("%s = %s, want %s", path, mustJSON(got), mustJSON(want))
+	}
+	return r
+}
+
+// lookupJSONPath 在解析之后的 json 中查找 path 对应的值
+func lookupJSONPath(v interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return v, true
+	}
+	for _, part := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			next, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// normalizeJSON 将 v 编码再解码，使得期望值和响应体使用相同的类型比较
+func normalizeJSON(v interface{}, out *interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func jsonEqual(a, b interface{}) bool {
+	return mustJSON(a) == mustJSON(b)
+}
+
+func mustJSON(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
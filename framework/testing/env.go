@@ -0,0 +1,68 @@
+package testing
+
+import (
+	"time"
+
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/provider/env"
+)
+
+// MemoryEnv 内存中的环境变量服务，默认的环境为 testing
+type MemoryEnv struct {
+	maps map[string]string
+}
+
+// NewMemoryEnv 使用 maps 初始化环境变量，没有设置 APP_ENV 的时候使用 testing 环境
+func NewMemoryEnv(maps map[string]string) *MemoryEnv {
+	env := &MemoryEnv{maps: map[string]string{"APP_ENV": contract.EnvTesting}}
+	for k, v := range maps {
+		env.maps[k] = v
+	}
+	return env
+}
+
+func (m *MemoryEnv) AppEnv() string {
+	return m.Get("APP_ENV")
+}
+
+func (m *MemoryEnv) Get(key string) string {
+	return m.maps[key]
+}
+
+func (m *MemoryEnv) IsExist(key string) bool {
+	_, ok := m.maps[key]
+	return ok
+}
+
+func (m *MemoryEnv) All() map[string]string {
+	return m.maps
+}
+
+func (m *MemoryEnv) GetInt(key string, def int) int {
+	if val, err := env.ParseValue(contract.EnvTypeInt, m.Get(key)); err == nil {
+		return val.(int)
+	}
+	return def
+}
+
+func (m *MemoryEnv) GetBool(key string, def bool) bool {
+	if val, err := env.ParseValue(contract.EnvTypeBool, m.Get(key)); err == nil {
+		return val.(bool)
+	}
+	return def
+}
+
+func (m *MemoryEnv) GetDuration(key string, def time.Duration) time.Duration {
+	if val, err := env.ParseValue(contract.EnvTypeDuration, m.Get(key)); err == nil {
+		return val.(time.Duration)
+	}
+	return def
+}
+
+func (m *MemoryEnv) GetStringSlice(key string, def []string) []string {
+	if !m.IsExist(key) {
+		return def
+	}
+	val, _ := env.ParseValue(contract.EnvTypeList, m.Get(key))
+	return val.([]string)
+}
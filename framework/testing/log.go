@@ -0,0 +1,49 @@
+package testing
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+)
+
+// LogCapture 收集日志服务输出的内容，用于在测试中断言日志
+type LogCapture struct {
+	lock sync.Mutex
+	buf  bytes.Buffer
+}
+
+func (l *LogCapture) Write(p []byte) (int, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.buf.Write(p)
+}
+
+// Lines 返回收集到的每一行日志
+func (l *LogCapture) Lines() []string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var lines []string
+	for _, line := range strings.Split(l.buf.String(), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// Contains 判断是否有某一行日志包含 s
+func (l *LogCapture) Contains(s string) bool {
+	for _, line := range l.Lines() {
+		if strings.Contains(line, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset 清空收集到的日志
+func (l *LogCapture) Reset() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.buf.Reset()
+}
@@ -0,0 +1,31 @@
+package testing
+
+import "github.com/yefangyong/go-frame/framework"
+
+// instanceProvider 直接返回给定实例的服务提供者，用于在测试中替换服务
+type instanceProvider struct {
+	key      string
+	instance interface{}
+}
+
+func (p *instanceProvider) Register(container framework.Container) framework.NewInstance {
+	return func(params ...interface{}) (interface{}, error) {
+		return p.instance, nil
+	}
+}
+
+func (p *instanceProvider) Boot(container framework.Container) error {
+	return nil
+}
+
+func (p *instanceProvider) IsDefer() bool {
+	return true
+}
+
+func (p *instanceProvider) Params(container framework.Container) []interface{} {
+	return nil
+}
+
+func (p *instanceProvider) Name() string {
+	return p.key
+}
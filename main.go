@@ -1,14 +1,22 @@
 package main
 
 import (
+	pkgLog "log"
+	"os"
+
 	"github.com/yefangyong/go-frame/app/console"
 	"github.com/yefangyong/go-frame/app/http"
+	"github.com/yefangyong/go-frame/app/module/demo"
 	"github.com/yefangyong/go-frame/framework"
+	"github.com/yefangyong/go-frame/framework/command"
+	"github.com/yefangyong/go-frame/framework/contract"
+	"github.com/yefangyong/go-frame/framework/module"
 	"github.com/yefangyong/go-frame/framework/provider/app"
 	"github.com/yefangyong/go-frame/framework/provider/cache"
 	"github.com/yefangyong/go-frame/framework/provider/config"
 	"github.com/yefangyong/go-frame/framework/provider/distributed/local"
 	"github.com/yefangyong/go-frame/framework/provider/env"
+	"github.com/yefangyong/go-frame/framework/provider/health"
 	"github.com/yefangyong/go-frame/framework/provider/kernel"
 	"github.com/yefangyong/go-frame/framework/provider/log"
 	"github.com/yefangyong/go-frame/framework/provider/log/formatter"
@@ -16,20 +24,35 @@ import (
 	"github.com/yefangyong/go-frame/framework/provider/redis"
 )
 
+// envVars 应用使用的环境变量，必须设置的变量缺失的时候启动失败，可以使用 hade env check 检查
+var envVars = []contract.EnvVar{
+	{Name: "APP_ENV", Description: "运行环境，development、testing 或者 production", Required: true},
+	{Name: config.SecretKeyEnv, Description: "解密配置中 enc(...) 的密钥", Secret: true},
+	{Name: config.SecretKeyFileEnv, Description: "解密配置中 enc(...) 的密钥文件"},
+}
+
 func main() {
 	container := framework.NewHadeContainer()
 	container.Bind(&app.HadeAppProvider{})
-	container.Bind(&env.HadeEnvProvider{})
+	// hade env check 需要输出完整的报告，不在创建 env 服务的时候检查
+	if err := container.Bind(&env.HadeEnvProvider{Vars: envVars, SkipCheck: command.IsEnvCheck(os.Args[1:])}); err != nil {
+		pkgLog.Fatalln(err)
+	}
 	container.Bind(&local.DistributedProvider{})
 	container.Bind(&config.HadeConfigProvider{})
 	container.Bind(&orm.GormProvider{})
 	container.Bind(&cache.HadeCacheProvider{})
 	container.Bind(&redis.RedisProvider{})
+	container.Bind(&health.HadeHealthProvider{})
 	container.Bind(&log.HadeLogServiceProvider{
 		Driver:    "single",
 		Formatter: formatter.JsonFormatter,
 	})
-	if engine, err := http.NewHttpEngine(); err == nil {
+	// 注册业务模块，模块的服务提供者在这里绑定，路由和命令由对应的内核注册
+	if err := module.Register(container, &demo.Module{}); err != nil {
+		pkgLog.Fatalln(err)
+	}
+	if engine, err := http.NewHttpEngine(container); err == nil {
 		container.Bind(&kernel.HadeKernelProvider{
 			HttpEngine: engine,
 		})